@@ -0,0 +1,175 @@
+package ai
+
+import (
+	"sort"
+
+	"github.com/brickman1444/NSImperialism/databasemap"
+	"github.com/brickman1444/NSImperialism/nationstates_api"
+	"github.com/brickman1444/NSImperialism/war"
+)
+
+// EmpireID uses a character NationStates doesn't allow in nation names so it can't collide with a real nation
+const EmpireID = "~empire"
+
+// The empire ranks the same on every scale so it's equally strong whichever combat model a map uses
+const empireCensusRank = 30
+
+// The empire grants few civil rights or political freedoms so the territories it conquers are restless
+const empireRepressionRank = 90
+const maximumOngoingOffensiveWars = 2
+const maximumRegimentsRecruitedPerWar = 2
+
+type Player interface {
+	GetNation() nationstates_api.Nation
+	ChooseWarTarget(databaseMap databasemap.DatabaseMap, warTargets []string, nationStatesProvider nationstates_api.NationStatesProvider) (string, error)
+	CommandArmies(databaseMap *databasemap.DatabaseMap, areNeighbours databasemap.AreNeighbours) error
+}
+
+type ExpansionistPlayer struct {
+	nation nationstates_api.Nation
+}
+
+func NewEmpire() ExpansionistPlayer {
+	nation := nationstates_api.Nation{
+		Id:      EmpireID,
+		Name:    "The Empire",
+		Demonym: "Imperial",
+		FlagURL: "/assets/uswds-2.10.0/img/flag.svg",
+	}
+	for _, scale := range nationstates_api.CombatCensusScales {
+		nation.SetCensusRank(empireCensusRank, scale)
+	}
+	for _, scale := range nationstates_api.UnrestCensusScales {
+		nation.SetCensusRank(empireRepressionRank, scale)
+	}
+
+	return ExpansionistPlayer{nation: nation}
+}
+
+func (player ExpansionistPlayer) GetNation() nationstates_api.Nation {
+	return player.nation
+}
+
+func countOngoingOffensiveWars(databaseMap databasemap.DatabaseMap, nationID string) int {
+	count := 0
+	for _, databaseWar := range databaseMap.Wars {
+		if databaseWar.IsOngoing && databaseWar.Attacker == nationID {
+			count++
+		}
+	}
+	return count
+}
+
+// ChooseWarTarget picks the territory held by the weakest nation, breaking ties by territory ID
+func (player ExpansionistPlayer) ChooseWarTarget(databaseMap databasemap.DatabaseMap, warTargets []string, nationStatesProvider nationstates_api.NationStatesProvider) (string, error) {
+
+	if countOngoingOffensiveWars(databaseMap, player.nation.Id) >= maximumOngoingOffensiveWars {
+		return "", nil
+	}
+
+	sortedWarTargets := make([]string, len(warTargets))
+	copy(sortedWarTargets, warTargets)
+	sort.Strings(sortedWarTargets)
+
+	censusWeights := war.GetCensusWeights(databaseMap)
+
+	bestTarget := ""
+	bestTargetStrength := -1
+	for _, territoryID := range sortedWarTargets {
+
+		resident, err := databaseMap.GetResident(territoryID)
+		if err != nil {
+			return "", err
+		}
+
+		residentNation, err := nationStatesProvider.GetNationData(resident)
+		if err != nil {
+			return "", err
+		}
+
+		residentStrength := war.Strength(*residentNation, censusWeights)
+		if bestTargetStrength == -1 || residentStrength < bestTargetStrength {
+			bestTarget = territoryID
+			bestTargetStrength = residentStrength
+		}
+	}
+
+	return bestTarget, nil
+}
+
+// CommandArmies raises regiments next to the territory of each war the empire started and marches every army beside it in
+func (player ExpansionistPlayer) CommandArmies(databaseMap *databasemap.DatabaseMap, areNeighbours databasemap.AreNeighbours) error {
+	playerID := player.nation.Id
+
+	for _, databaseWar := range databaseMap.GetWars() {
+		if !databaseWar.IsOngoing || databaseWar.Attacker != playerID {
+			continue
+		}
+
+		target := databaseWar.TerritoryName
+
+		for _, territoryID := range databaseMap.GetCellIDs() {
+			if databaseMap.Cells[territoryID].Resident == playerID && areNeighbours(territoryID, target) {
+				for regiment := 0; regiment < maximumRegimentsRecruitedPerWar; regiment++ {
+					if databaseMap.Recruit(playerID, territoryID) != nil {
+						break
+					}
+				}
+				break
+			}
+		}
+
+		for _, army := range databaseMap.GetArmiesOf(playerID) {
+			if areNeighbours(army.TerritoryID, target) {
+				err := databaseMap.OrderArmyMove(army.ID, playerID, target, areNeighbours)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+var expansionistInterfaceChecker Player = ExpansionistPlayer{}
+
+func IsPresent(player Player, databaseMap databasemap.DatabaseMap) bool {
+	playerID := player.GetNation().Id
+	for _, cell := range databaseMap.Cells {
+		if cell.Resident == playerID {
+			return true
+		}
+	}
+	return false
+}
+
+type NationStatesProviderWithAI struct {
+	nationStatesProvider nationstates_api.NationStatesProvider
+	players              map[string]Player
+}
+
+func NewNationStatesProviderWithAI(nationStatesProvider nationstates_api.NationStatesProvider, players []Player) NationStatesProviderWithAI {
+	provider := NationStatesProviderWithAI{
+		nationStatesProvider: nationStatesProvider,
+		players:              make(map[string]Player),
+	}
+
+	for _, player := range players {
+		provider.players[player.GetNation().Id] = player
+	}
+
+	return provider
+}
+
+func (provider NationStatesProviderWithAI) GetNationData(nationName string) (*nationstates_api.Nation, error) {
+	player, isAIPlayer := provider.players[nationName]
+	if isAIPlayer {
+		nation := player.GetNation()
+		return &nation, nil
+	}
+
+	return provider.nationStatesProvider.GetNationData(nationName)
+}
+
+var providerInterfaceChecker nationstates_api.NationStatesProvider = NationStatesProviderWithAI{}
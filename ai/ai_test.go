@@ -0,0 +1,153 @@
+package ai
+
+import (
+	"testing"
+
+	"github.com/brickman1444/NSImperialism/databasemap"
+	"github.com/brickman1444/NSImperialism/nationstates_api"
+	"github.com/stretchr/testify/assert"
+)
+
+func makeTestProvider() nationstates_api.NationStatesProviderSimpleMap {
+	nationStatesProvider := nationstates_api.NewNationStatesProviderSimpleMap()
+
+	strong := nationstates_api.Nation{Id: "strong"}
+	strong.SetDefenseForces(10)
+	nationStatesProvider.PutNationData(strong)
+
+	weak := nationstates_api.Nation{Id: "weak"}
+	weak.SetDefenseForces(90)
+	nationStatesProvider.PutNationData(weak)
+
+	return nationStatesProvider
+}
+
+func TestEmpireChoosesTheWeakestTarget(t *testing.T) {
+
+	databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A", "B", "C"})
+	databaseMap.SetResident("A", EmpireID)
+	databaseMap.SetResident("B", "strong")
+	databaseMap.SetResident("C", "weak")
+
+	target, err := NewEmpire().ChooseWarTarget(databaseMap, []string{"B", "C"}, makeTestProvider())
+	assert.NoError(t, err)
+	assert.Equal(t, "C", target)
+}
+
+func TestEmpireJudgesWeaknessByTheMapsCombatModel(t *testing.T) {
+
+	nationStatesProvider := makeTestProvider()
+	scientific := nationstates_api.Nation{Id: "scientific"}
+	scientific.SetDefenseForces(95)
+	scientific.SetCensusRank(5, nationstates_api.CENSUSSCALESCIENTIFICADVANCEMENT)
+	nationStatesProvider.PutNationData(scientific)
+	backward := nationstates_api.Nation{Id: "backward"}
+	backward.SetDefenseForces(50)
+	backward.SetCensusRank(90, nationstates_api.CENSUSSCALESCIENTIFICADVANCEMENT)
+	nationStatesProvider.PutNationData(backward)
+
+	databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A", "B", "C"})
+	databaseMap.SetResident("A", EmpireID)
+	databaseMap.SetResident("B", "scientific")
+	databaseMap.SetResident("C", "backward")
+
+	target, err := NewEmpire().ChooseWarTarget(databaseMap, []string{"B", "C"}, nationStatesProvider)
+	assert.NoError(t, err)
+	assert.Equal(t, "B", target)
+
+	databaseMap.CensusWeights = []databasemap.DatabaseCensusWeight{
+		{Scale: nationstates_api.CENSUSSCALEDEFENSEFORCES, Weight: 1},
+		{Scale: nationstates_api.CENSUSSCALESCIENTIFICADVANCEMENT, Weight: 1},
+	}
+
+	target, err = NewEmpire().ChooseWarTarget(databaseMap, []string{"B", "C"}, nationStatesProvider)
+	assert.NoError(t, err)
+	assert.Equal(t, "C", target)
+}
+
+func TestEmpireBreaksTiesByTerritoryID(t *testing.T) {
+
+	databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A", "B", "C"})
+	databaseMap.SetResident("A", EmpireID)
+	databaseMap.SetResident("B", "weak")
+	databaseMap.SetResident("C", "weak")
+
+	target, err := NewEmpire().ChooseWarTarget(databaseMap, []string{"C", "B"}, makeTestProvider())
+	assert.NoError(t, err)
+	assert.Equal(t, "B", target)
+}
+
+func TestEmpireWithNoTargetsDoesntAttack(t *testing.T) {
+
+	databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A"})
+	databaseMap.SetResident("A", EmpireID)
+
+	target, err := NewEmpire().ChooseWarTarget(databaseMap, []string{}, makeTestProvider())
+	assert.NoError(t, err)
+	assert.Empty(t, target)
+}
+
+func TestEmpireDoesntStartTooManyWars(t *testing.T) {
+
+	databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A", "B", "C", "D"})
+	databaseMap.SetResident("A", EmpireID)
+	databaseMap.SetResident("B", "weak")
+	databaseMap.SetResident("C", "weak")
+	databaseMap.SetResident("D", "weak")
+	databaseMap.PutWars([]databasemap.DatabaseWar{
+		databasemap.NewWar(EmpireID, "weak", "warAtB", "B", 0),
+		databasemap.NewWar(EmpireID, "weak", "warAtC", "C", 0),
+	})
+
+	target, err := NewEmpire().ChooseWarTarget(databaseMap, []string{"D"}, makeTestProvider())
+	assert.NoError(t, err)
+	assert.Empty(t, target)
+}
+
+func TestEmpireIsPresentOnlyWhenItHoldsATerritory(t *testing.T) {
+
+	databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A"})
+	databaseMap.SetResident("A", "weak")
+	assert.False(t, IsPresent(NewEmpire(), databaseMap))
+
+	databaseMap.SetResident("A", EmpireID)
+	assert.True(t, IsPresent(NewEmpire(), databaseMap))
+}
+
+func TestProviderWithAIReturnsAIAndRealNations(t *testing.T) {
+
+	provider := NewNationStatesProviderWithAI(makeTestProvider(), []Player{NewEmpire()})
+
+	empire, err := provider.GetNationData(EmpireID)
+	assert.NoError(t, err)
+	assert.Equal(t, EmpireID, empire.Id)
+
+	weak, err := provider.GetNationData("weak")
+	assert.NoError(t, err)
+	assert.Equal(t, 90, weak.GetDefenseForces())
+
+	_, err = provider.GetNationData("doesnt_exist")
+	assert.Error(t, err)
+}
+
+func TestEmpireRaisesRegimentsBesideItsTargetAndMarchesOnIt(t *testing.T) {
+
+	databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A", "B", "C"})
+	databaseMap.SetResident("A", EmpireID)
+	databaseMap.SetResident("B", "weak")
+	databaseMap.SetResident("C", EmpireID)
+	databaseMap.Treasuries[EmpireID] = 10 * databasemap.RecruitCost
+	databaseMap.PutWars([]databasemap.DatabaseWar{databasemap.NewWar(EmpireID, "weak", "warAtB", "B", 0)})
+
+	areNeighbours := func(territoryA string, territoryB string) bool {
+		return (territoryA == "A" && territoryB == "B") || (territoryA == "B" && territoryB == "A")
+	}
+
+	assert.NoError(t, NewEmpire().CommandArmies(&databaseMap, areNeighbours))
+
+	armies := databaseMap.GetArmiesOf(EmpireID)
+	assert.Len(t, armies, 1)
+	assert.Equal(t, "A", armies[0].TerritoryID)
+	assert.Equal(t, maximumRegimentsRecruitedPerWar, armies[0].Regiments)
+	assert.Equal(t, "B", armies[0].Destination)
+}
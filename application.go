@@ -1,21 +1,25 @@
 package main
 
 import (
-	"crypto/sha1"
+	cryptorand "crypto/rand"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"html/template"
 	"log"
 	"math/rand"
 	"net/http"
+	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/brickman1444/NSImperialism/ai"
 	"github.com/brickman1444/NSImperialism/databasemap"
-	"github.com/brickman1444/NSImperialism/dynamodbwrapper"
 	"github.com/brickman1444/NSImperialism/nationstates_api"
+	"github.com/brickman1444/NSImperialism/repository"
 	"github.com/brickman1444/NSImperialism/session"
 	"github.com/brickman1444/NSImperialism/strategicmap"
 	"github.com/brickman1444/NSImperialism/war"
@@ -24,10 +28,11 @@ import (
 	"github.com/joho/godotenv"
 )
 
-var globalMaps = strategicmap.MapsDatabase{}
-var globalStrategicMap = strategicmap.StaticMap
-var globalSessionManager = session.SessionManagerDatabase{}
-var globalNationStatesProvider = nationstates_api.NationStatesProviderAPI{}
+var globalRepository repository.Repository
+var globalLayouts = []strategicmap.Map{strategicmap.StaticMap}
+var globalSessionManager session.SessionManager
+var globalAIPlayers = []ai.Player{ai.NewEmpire()}
+var globalNationStatesProvider = ai.NewNationStatesProviderWithAI(nationstates_api.NationStatesProviderAPI{}, globalAIPlayers)
 
 const SESSION_COOKIE_NAME = "SessionID"
 const SESSION_COOKIE_SEPARATOR = ":"
@@ -100,17 +105,11 @@ func renderPage(w http.ResponseWriter, bodyTemplateFileName string, data interfa
 }
 
 func getParticipatingNations(databaseMap databasemap.DatabaseMap) ([]nationstates_api.Nation, error) {
-	uniqueParticipantNationIDs := []string{}
-	for _, cell := range databaseMap.Cells {
-		if !contains(uniqueParticipantNationIDs, cell.Resident) {
-			uniqueParticipantNationIDs = append(uniqueParticipantNationIDs, cell.Resident)
-		}
-	}
 
 	nations := []nationstates_api.Nation{}
-	for _, nationID := range uniqueParticipantNationIDs {
+	for _, nationID := range databaseMap.Participants {
 
-		nation, err := nationstates_api.GetNationData(nationID)
+		nation, err := globalNationStatesProvider.GetNationData(nationID)
 		if err != nil {
 			return []nationstates_api.Nation{}, err
 		}
@@ -121,18 +120,29 @@ func getParticipatingNations(databaseMap databasemap.DatabaseMap) ([]nationstate
 	return nations, nil
 }
 
+const myMapsFilter = "mine"
+
 func indexHandler(w http.ResponseWriter, r *http.Request) {
 
 	loggedInNation := getLoggedInNationFromCookie(r)
 
-	maps, err := dynamodbwrapper.GetAllMaps()
+	query := repository.MapQuery{Cursor: r.FormValue("cursor")}
+
+	filter := r.FormValue("filter")
+	if filter == myMapsFilter && loggedInNation != nil {
+		query.Participant = loggedInNation.Id
+	} else {
+		filter = ""
+	}
+
+	listing, err := listMaps(query)
 	if err != nil {
 		ErrorHandler(w, r, "Failed to get map IDs")
 		return
 	}
 
 	mapLinkDatas := []MapLinkData{}
-	for _, databaseMap := range maps {
+	for _, databaseMap := range listing.Maps {
 
 		participatingNations, err := getParticipatingNations(databaseMap)
 		if err != nil {
@@ -147,7 +157,25 @@ func indexHandler(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
-	page := &Page{LoggedInNation: loggedInNation, Maps: mapLinkDatas}
+	page := &Page{
+		LoggedInNation:                 loggedInNation,
+		Maps:                           mapLinkDatas,
+		Filter:                         filter,
+		NextCursor:                     listing.NextCursor,
+		CombatModels:                   war.CombatModels,
+		DefaultOrderPhaseHours:         databasemap.DefaultOrderPhaseHours,
+		MaximumOrderPhaseHours:         databasemap.MaximumOrderPhaseHours,
+		Cadences:                       databasemap.Cadences,
+		VictoryConditions:              databasemap.VictoryConditions,
+		DefaultVictoryTerritoryPercent: databasemap.DefaultVictoryTerritoryPercent,
+		MinimumVictoryTerritoryPercent: databasemap.MinimumVictoryTerritoryPercent,
+		DefaultVictoryYearLimit:        databasemap.DefaultVictoryYearLimit,
+		Layouts:                        globalLayouts,
+		TerritoryCount:                 getLargestTerritoryCount(globalLayouts),
+		MinimumGeneratedTerritories:    strategicmap.MinimumGeneratedTerritories,
+		MaximumGeneratedTerritories:    strategicmap.MaximumGeneratedTerritories,
+		DefaultGeneratedTerritories:    strategicmap.DefaultGeneratedTerritories,
+	}
 
 	renderPage(w, "index.html", page)
 }
@@ -159,25 +187,85 @@ type MapLinkData struct {
 }
 
 type Page struct {
-	Wars           []war.RenderedWar
-	Map            strategicmap.RenderedMap
-	Year           int
-	LoggedInNation *nationstates_api.Nation
-	Maps           []MapLinkData
-	MapID          string
-	Error          string
+	Wars                           []war.RenderedWar
+	Map                            strategicmap.RenderedMap
+	Year                           int
+	LoggedInNation                 *nationstates_api.Nation
+	Maps                           []MapLinkData
+	MapID                          string
+	Error                          string
+	Filter                         string
+	NextCursor                     string
+	CombatModels                   []war.CombatModel
+	DefaultOrderPhaseHours         int
+	MaximumOrderPhaseHours         int
+	Cadences                       []databasemap.Cadence
+	VictoryConditions              []databasemap.VictoryCondition
+	DefaultVictoryTerritoryPercent int
+	MinimumVictoryTerritoryPercent int
+	DefaultVictoryYearLimit        int
+	Layouts                        []strategicmap.Map
+	TerritoryCount                 int
+	MinimumGeneratedTerritories    int
+	MaximumGeneratedTerritories    int
+	DefaultGeneratedTerritories    int
+}
+
+func getLargestTerritoryCount(layouts []strategicmap.Map) int {
+	largestTerritoryCount := strategicmap.MaximumGeneratedTerritories
+	for _, layout := range layouts {
+		if len(layout.Territories) > largestTerritoryCount {
+			largestTerritoryCount = len(layout.Territories)
+		}
+	}
+	return largestTerritoryCount
 }
 
-func canAttack(nation nationstates_api.Nation, territory databasemap.DatabaseCell, wars []databasemap.DatabaseWar) (bool, string) {
+// getLayout finds the board a map is played on
+func getLayout(databaseMap databasemap.DatabaseMap) (strategicmap.Map, error) {
+	if databaseMap.GeneratedLayout != nil {
+		return strategicmap.FromDatabaseLayout(databaseMap.LayoutID, databaseMap.ID, *databaseMap.GeneratedLayout), nil
+	}
+
+	layout, doesLayoutExist := strategicmap.FindLayout(globalLayouts, databaseMap.LayoutID)
+	if !doesLayoutExist {
+		return layout, fmt.Errorf("This map's layout %s is no longer available", databaseMap.LayoutID)
+	}
+	return layout, nil
+}
+
+func bordersTerritoryHeldBy(nationID string, territoryID string, databaseMap databasemap.DatabaseMap, strategicMap strategicmap.Map) bool {
+	for _, neighbourID := range strategicMap.Neighbours(territoryID) {
+		neighbour, doesNeighbourExist := databaseMap.Cells[neighbourID]
+		if doesNeighbourExist && neighbour.Resident == nationID {
+			return true
+		}
+	}
+	return false
+}
+
+func canAttack(nation nationstates_api.Nation, territory databasemap.DatabaseCell, databaseMap databasemap.DatabaseMap, strategicMap strategicmap.Map) (bool, string) {
+	if databaseMap.IsFinished {
+		return false, databasemap.MapFinishedError.Error()
+	}
+
 	if territory.Resident == "" {
-		return false, fmt.Sprintf("No nation resides in %s", territory.ID)
+		return false, fmt.Sprintf("No nation resides in %s. Colonise it instead.", territory.ID)
 	}
 
 	if territory.Resident == nation.Id {
 		return false, "You can't attack yourself"
 	}
 
-	currentWar := war.FindOngoingWarAt(wars, territory.ID)
+	if databaseMap.AreAllied(nation.Id, territory.Resident) {
+		return false, "You can't attack an ally"
+	}
+
+	if !bordersTerritoryHeldBy(nation.Id, territory.ID, databaseMap, strategicMap) {
+		return false, fmt.Sprintf("You don't control a territory bordering %s", territory.ID)
+	}
+
+	currentWar := war.FindOngoingWarAt(databaseMap.GetWars(), territory.ID)
 	if currentWar != nil {
 		return false, fmt.Sprintf("There is already a war at %s", territory.ID)
 	}
@@ -185,6 +273,202 @@ func canAttack(nation nationstates_api.Nation, territory databasemap.DatabaseCel
 	return true, ""
 }
 
+func declareWar(databaseMap *databasemap.DatabaseMap, strategicMap strategicmap.Map, attacker nationstates_api.Nation, target string, occasion databasemap.Occasion, nationStatesProvider nationstates_api.NationStatesProvider) error {
+
+	targetTerritory, doesTerritoryExist := databaseMap.Cells[target]
+	if !doesTerritoryExist {
+		return errors.New("That territory doesn't exist")
+	}
+
+	canAttack, canAttackReason := canAttack(attacker, targetTerritory, *databaseMap, strategicMap)
+	if !canAttack {
+		return errors.New(canAttackReason)
+	}
+
+	err := war.CheckOccasion(occasion, attacker.Id, targetTerritory)
+	if err != nil {
+		return err
+	}
+
+	warName := fmt.Sprintf("The %s %s %s", attacker.Demonym, occasion.WarNamePhrase(), target)
+
+	defender, err := nationStatesProvider.GetNationData(targetTerritory.Resident)
+	if err != nil {
+		return fmt.Errorf("Failed to get defender data for %s", targetTerritory.Resident)
+	}
+
+	newWar := databasemap.NewWar(attacker.Id, defender.Id, warName, target, databaseMap.Year)
+	newWar.Occasion = occasion
+	if occasion == databasemap.Liberation {
+		newWar.LiberatedFor = targetTerritory.FormerResident()
+	}
+	databaseMap.PutWars([]databasemap.DatabaseWar{newWar})
+
+	return nil
+}
+
+// carryOutOrder does what the order says on the map as it is now
+func carryOutOrder(databaseMap *databasemap.DatabaseMap, strategicMap strategicmap.Map, order databasemap.DatabaseOrder, nationStatesProvider nationstates_api.NationStatesProvider) error {
+	switch order.Kind {
+	case databasemap.DeclareWarOrder:
+		attacker, err := nationStatesProvider.GetNationData(order.Nation)
+		if err != nil {
+			return fmt.Errorf("Failed to get attacker data for %s", order.Nation)
+		}
+		return declareWar(databaseMap, strategicMap, *attacker, order.TerritoryID, order.Occasion, nationStatesProvider)
+	case databasemap.JoinWarOrder:
+		return databaseMap.JoinWar(order.WarID, order.Nation, order.Side)
+	case databasemap.FundWarOrder:
+		return databaseMap.FundWar(order.WarID, order.Nation, order.Amount)
+	case databasemap.RecruitOrder:
+		return databaseMap.Recruit(order.Nation, order.TerritoryID)
+	case databasemap.MoveArmyOrder:
+		return databaseMap.OrderArmyMove(order.ArmyID, order.Nation, order.TerritoryID, strategicMap.AreNeighbours)
+	case databasemap.FortifyOrder:
+		return databaseMap.Fortify(order.TerritoryID, order.Nation)
+	case databasemap.ColoniseOrder:
+		return databaseMap.Colonise(order.TerritoryID, order.Nation, strategicMap.AreNeighbours)
+	case databasemap.OfferPeaceOrder:
+		return databaseMap.OfferPeace(order.WarID, order.Nation, order.Terms, order.CeasefireYears)
+	case databasemap.AcceptPeaceOrder:
+		return databaseMap.AcceptPeaceOffer(order.WarID, order.Nation)
+	case databasemap.RejectPeaceOrder:
+		return databaseMap.RejectPeaceOffer(order.WarID, order.Nation)
+	case databasemap.ProposeAllianceOrder:
+		return databaseMap.ProposeAlliance(order.Nation, order.OtherNation)
+	case databasemap.AcceptAllianceOrder:
+		return databaseMap.AcceptAlliance(order.Nation, order.OtherNation)
+	case databasemap.BreakAllianceOrder:
+		return databaseMap.BreakAlliance(order.Nation, order.OtherNation)
+	}
+
+	return errors.New("That isn't an order you can give")
+}
+
+// giveOrder checks the order against the map as the nation's orders will leave it and saves it for when the year resolves
+func giveOrder(databaseMap *databasemap.DatabaseMap, order databasemap.DatabaseOrder) error {
+	err := databaseMap.CheckCanGiveOrders(order.Nation)
+	if err != nil {
+		return err
+	}
+
+	layout, err := getLayout(*databaseMap)
+	if err != nil {
+		return err
+	}
+
+	givenOrders := databaseMap.GetOrdersOf(order.Nation)
+
+	trialMap, err := databaseMap.Copy()
+	if err != nil {
+		return errors.New("Failed to check the order")
+	}
+
+	for _, givenOrder := range databasemap.SortOrdersForResolving(givenOrders) {
+		err = carryOutOrder(&trialMap, layout, givenOrder, globalNationStatesProvider)
+		if err != nil {
+			return fmt.Errorf("One of your earlier orders this year is no longer possible: %s", err.Error())
+		}
+	}
+
+	trialMap, err = databaseMap.Copy()
+	if err != nil {
+		return errors.New("Failed to check the order")
+	}
+
+	for _, givenOrder := range databasemap.SortOrdersForResolving(append(givenOrders, order)) {
+		err = carryOutOrder(&trialMap, layout, givenOrder, globalNationStatesProvider)
+		if err != nil && givenOrder == order {
+			return err
+		}
+		if err != nil {
+			return fmt.Errorf("That order would leave one of your earlier orders this year impossible: %s", err.Error())
+		}
+	}
+
+	databaseMap.Orders = append(databaseMap.Orders, order)
+
+	return nil
+}
+
+// carryOutOrders does every nation's orders together, settling contested claims first, and records the orders that were no longer possible
+func carryOutOrders(databaseMap *databasemap.DatabaseMap, strategicMap strategicmap.Map, nationStatesProvider nationstates_api.NationStatesProvider) {
+	orders, droppedOrders := databaseMap.SettleClaims(databaseMap.Orders, strategicMap.AreNeighbours)
+
+	for _, order := range databasemap.SortOrdersForResolving(orders) {
+		err := carryOutOrder(databaseMap, strategicMap, order, nationStatesProvider)
+		if err != nil {
+			droppedOrders = append(droppedOrders, databasemap.DatabaseDroppedOrder{Order: order, Reason: err.Error()})
+		}
+	}
+
+	databaseMap.DroppedOrders = droppedOrders
+	databaseMap.Orders = []databasemap.DatabaseOrder{}
+}
+
+// normalizeMap brings a map up to date if an older version of the game saved it
+func normalizeMap(databaseMap *databasemap.DatabaseMap) {
+	databaseMap.Normalize()
+	if len(databaseMap.LayoutID) == 0 {
+		databaseMap.LayoutID = strategicmap.DefaultLayoutID
+	}
+}
+
+func getMap(mapID string) (databasemap.DatabaseMap, error) {
+	databaseMap, err := globalRepository.GetMap(mapID)
+	if err != nil {
+		return databaseMap, err
+	}
+
+	normalizeMap(&databaseMap)
+	return databaseMap, nil
+}
+
+func listMaps(query repository.MapQuery) (repository.MapListing, error) {
+	listing, err := globalRepository.ListMaps(query)
+	if err != nil {
+		return listing, err
+	}
+
+	for mapIndex := range listing.Maps {
+		normalizeMap(&listing.Maps[mapIndex])
+	}
+	return listing, nil
+}
+
+const maximumMapUpdateAttempts = 3
+
+// updateMap applies the change again to a fresh copy if someone else saved the map in the meantime
+func updateMap(mapID string, applyChange func(databaseMap *databasemap.DatabaseMap) error) error {
+
+	var err error
+	for attempt := 0; attempt < maximumMapUpdateAttempts; attempt++ {
+
+		databaseMap, getErr := getMap(mapID)
+		if getErr != nil {
+			return errors.New("Failed to get map")
+		}
+
+		err = applyChange(&databaseMap)
+		if err != nil {
+			return err
+		}
+
+		err = globalRepository.PutMap(databaseMap)
+		if err == nil {
+			return nil
+		}
+
+		if !databasemap.IsVersionConflict(err) {
+			return errors.New("Failed to save map")
+		}
+
+		log.Println("Retrying update of map", mapID, "after a version conflict")
+	}
+
+	return err
+}
+
 func warHandler(w http.ResponseWriter, r *http.Request) {
 
 	attacker := getLoggedInNationFromCookie(r)
@@ -196,221 +480,1211 @@ func warHandler(w http.ResponseWriter, r *http.Request) {
 	routeVariables := mux.Vars(r)
 	mapID := routeVariables["id"]
 
-	databaseMap, err := globalMaps.GetMap(mapID)
+	err := updateMap(mapID, func(databaseMap *databasemap.DatabaseMap) error {
+		return giveOrder(databaseMap, databasemap.DatabaseOrder{Nation: attacker.Id, Kind: databasemap.DeclareWarOrder, TerritoryID: r.FormValue("target"), Occasion: databasemap.Occasion(r.FormValue("occasion"))})
+	})
 	if err != nil {
-		ErrorHandler(w, r, "Failed to get map")
+		ErrorHandler(w, r, err.Error())
 		return
 	}
 
-	target := r.FormValue("target")
+	http.Redirect(w, r, "/maps/"+mapID, http.StatusSeeOther)
+}
 
-	targetTerritory, doesTerritoryExist := databaseMap.Cells[target]
-	if !doesTerritoryExist {
-		ErrorHandler(w, r, "That territory doesn't exist")
+func getAIPlayerIDs(aiPlayers []ai.Player) []string {
+	aiPlayerIDs := []string{}
+	for _, aiPlayer := range aiPlayers {
+		aiPlayerIDs = append(aiPlayerIDs, aiPlayer.GetNation().Id)
+	}
+	return aiPlayerIDs
+}
+
+func isAIPlayer(nationID string, aiPlayers []ai.Player) bool {
+	for _, aiPlayer := range aiPlayers {
+		if aiPlayer.GetNation().Id == nationID {
+			return true
+		}
+	}
+	return false
+}
+
+func updateAlliance(w http.ResponseWriter, r *http.Request, applyChange func(databaseMap *databasemap.DatabaseMap, nationID string, otherNationID string) error) {
+
+	loggedInNation := getLoggedInNationFromCookie(r)
+	if loggedInNation == nil {
+		ErrorHandler(w, r, "You must be logged in to manage alliances")
 		return
 	}
 
-	canAttack, canAttackReason := canAttack(*attacker, targetTerritory, databaseMap.GetWars())
-	if !canAttack {
-		ErrorHandler(w, r, canAttackReason)
+	otherNationID := r.FormValue("nation")
+	if isAIPlayer(otherNationID, globalAIPlayers) {
+		ErrorHandler(w, r, "The empire doesn't make alliances")
+		return
+	}
+
+	routeVariables := mux.Vars(r)
+	mapID := routeVariables["map_id"]
+
+	err := updateMap(mapID, func(databaseMap *databasemap.DatabaseMap) error {
+		return applyChange(databaseMap, loggedInNation.Id, otherNationID)
+	})
+	if err != nil {
+		ErrorHandler(w, r, err.Error())
+		return
+	}
+
+	http.Redirect(w, r, "/maps/"+mapID, http.StatusSeeOther)
+}
+
+func proposeAllianceHandler(w http.ResponseWriter, r *http.Request) {
+	updateAlliance(w, r, func(databaseMap *databasemap.DatabaseMap, nationID string, otherNationID string) error {
+		if !databaseMap.HasParticipant(nationID) {
+			return errors.New("You must be participating in this map to make alliances")
+		}
+		return giveOrder(databaseMap, databasemap.DatabaseOrder{Nation: nationID, Kind: databasemap.ProposeAllianceOrder, OtherNation: otherNationID})
+	})
+}
+
+func acceptAllianceHandler(w http.ResponseWriter, r *http.Request) {
+	updateAlliance(w, r, func(databaseMap *databasemap.DatabaseMap, nationID string, otherNationID string) error {
+		return giveOrder(databaseMap, databasemap.DatabaseOrder{Nation: nationID, Kind: databasemap.AcceptAllianceOrder, OtherNation: otherNationID})
+	})
+}
+
+func leaveAllianceHandler(w http.ResponseWriter, r *http.Request) {
+	updateAlliance(w, r, func(databaseMap *databasemap.DatabaseMap, nationID string, otherNationID string) error {
+		return giveOrder(databaseMap, databasemap.DatabaseOrder{Nation: nationID, Kind: databasemap.BreakAllianceOrder, OtherNation: otherNationID})
+	})
+}
+
+func joinWarHandler(w http.ResponseWriter, r *http.Request) {
+
+	loggedInNation := getLoggedInNationFromCookie(r)
+	if loggedInNation == nil {
+		ErrorHandler(w, r, "You must be logged in to join a war")
+		return
+	}
+
+	routeVariables := mux.Vars(r)
+	mapID := routeVariables["map_id"]
+
+	err := updateMap(mapID, func(databaseMap *databasemap.DatabaseMap) error {
+		return giveOrder(databaseMap, databasemap.DatabaseOrder{Nation: loggedInNation.Id, Kind: databasemap.JoinWarOrder, WarID: r.FormValue("war_id"), Side: r.FormValue("side")})
+	})
+	if err != nil {
+		ErrorHandler(w, r, err.Error())
+		return
+	}
+
+	http.Redirect(w, r, "/maps/"+mapID, http.StatusSeeOther)
+}
+
+func updatePeace(w http.ResponseWriter, r *http.Request, applyChange func(databaseMap *databasemap.DatabaseMap, nationID string, warID string) error) {
+
+	loggedInNation := getLoggedInNationFromCookie(r)
+	if loggedInNation == nil {
+		ErrorHandler(w, r, "You must be logged in to negotiate peace")
+		return
+	}
+
+	routeVariables := mux.Vars(r)
+	mapID := routeVariables["map_id"]
+
+	err := updateMap(mapID, func(databaseMap *databasemap.DatabaseMap) error {
+		return applyChange(databaseMap, loggedInNation.Id, r.FormValue("war_id"))
+	})
+	if err != nil {
+		ErrorHandler(w, r, err.Error())
+		return
+	}
+
+	http.Redirect(w, r, "/maps/"+mapID, http.StatusSeeOther)
+}
+
+func offerPeaceHandler(w http.ResponseWriter, r *http.Request) {
+	updatePeace(w, r, func(databaseMap *databasemap.DatabaseMap, nationID string, warID string) error {
+		ceasefireYears := 0
+		if r.FormValue("terms") == databasemap.CeasefireTerms {
+			var err error
+			ceasefireYears, err = strconv.Atoi(r.FormValue("ceasefire_years"))
+			if err != nil {
+				return errors.New("You didn't choose a valid length of ceasefire")
+			}
+		}
+
+		return giveOrder(databaseMap, databasemap.DatabaseOrder{Nation: nationID, Kind: databasemap.OfferPeaceOrder, WarID: warID, Terms: r.FormValue("terms"), CeasefireYears: ceasefireYears})
+	})
+}
+
+func acceptPeaceHandler(w http.ResponseWriter, r *http.Request) {
+	updatePeace(w, r, func(databaseMap *databasemap.DatabaseMap, nationID string, warID string) error {
+		return giveOrder(databaseMap, databasemap.DatabaseOrder{Nation: nationID, Kind: databasemap.AcceptPeaceOrder, WarID: warID})
+	})
+}
+
+func rejectPeaceHandler(w http.ResponseWriter, r *http.Request) {
+	updatePeace(w, r, func(databaseMap *databasemap.DatabaseMap, nationID string, warID string) error {
+		return giveOrder(databaseMap, databasemap.DatabaseOrder{Nation: nationID, Kind: databasemap.RejectPeaceOrder, WarID: warID})
+	})
+}
+
+func recruitHandler(w http.ResponseWriter, r *http.Request) {
+
+	loggedInNation := getLoggedInNationFromCookie(r)
+	if loggedInNation == nil {
+		ErrorHandler(w, r, "You must be logged in to recruit regiments")
+		return
+	}
+
+	routeVariables := mux.Vars(r)
+	mapID := routeVariables["map_id"]
+
+	err := updateMap(mapID, func(databaseMap *databasemap.DatabaseMap) error {
+		return giveOrder(databaseMap, databasemap.DatabaseOrder{Nation: loggedInNation.Id, Kind: databasemap.RecruitOrder, TerritoryID: r.FormValue("territory_id")})
+	})
+	if err != nil {
+		ErrorHandler(w, r, err.Error())
+		return
+	}
+
+	http.Redirect(w, r, "/maps/"+mapID, http.StatusSeeOther)
+}
+
+func orderArmyHandler(w http.ResponseWriter, r *http.Request) {
+
+	loggedInNation := getLoggedInNationFromCookie(r)
+	if loggedInNation == nil {
+		ErrorHandler(w, r, "You must be logged in to give an army orders")
+		return
+	}
+
+	routeVariables := mux.Vars(r)
+	mapID := routeVariables["map_id"]
+	armyID := routeVariables["army_id"]
+
+	err := updateMap(mapID, func(databaseMap *databasemap.DatabaseMap) error {
+		return giveOrder(databaseMap, databasemap.DatabaseOrder{Nation: loggedInNation.Id, Kind: databasemap.MoveArmyOrder, ArmyID: armyID, TerritoryID: r.FormValue("destination")})
+	})
+	if err != nil {
+		ErrorHandler(w, r, err.Error())
+		return
+	}
+
+	http.Redirect(w, r, "/maps/"+mapID, http.StatusSeeOther)
+}
+
+func fundWarHandler(w http.ResponseWriter, r *http.Request) {
+
+	loggedInNation := getLoggedInNationFromCookie(r)
+	if loggedInNation == nil {
+		ErrorHandler(w, r, "You must be logged in to fund a war")
 		return
 	}
 
-	occasion := r.FormValue("occasion")
-	if len(occasion) == 0 {
-		ErrorHandler(w, r, "You didn't choose a valid occasion for war")
+	amount, err := strconv.Atoi(r.FormValue("amount"))
+	if err != nil {
+		ErrorHandler(w, r, "You didn't choose a valid amount of gold")
 		return
 	}
 
-	warName := fmt.Sprintf("The %s %s %s", attacker.Demonym, occasion, target)
+	routeVariables := mux.Vars(r)
+	mapID := routeVariables["map_id"]
 
-	defender, err := nationstates_api.GetNationData(targetTerritory.Resident)
+	err = updateMap(mapID, func(databaseMap *databasemap.DatabaseMap) error {
+		return giveOrder(databaseMap, databasemap.DatabaseOrder{Nation: loggedInNation.Id, Kind: databasemap.FundWarOrder, WarID: r.FormValue("war_id"), Amount: amount})
+	})
 	if err != nil {
-		ErrorHandler(w, r, fmt.Sprintf("Failed to get defender data for %s", targetTerritory.Resident))
+		ErrorHandler(w, r, err.Error())
 		return
 	}
 
-	if attacker != nil && len(warName) != 0 {
-		newWar := databasemap.NewWar(attacker.Id, defender.Id, warName, target, databaseMap.Year)
-		databaseMap.PutWars([]databasemap.DatabaseWar{newWar})
+	http.Redirect(w, r, "/maps/"+mapID, http.StatusSeeOther)
+}
+
+func tickHandler(w http.ResponseWriter, r *http.Request) {
+
+	loggedInNation := getLoggedInNationFromCookie(r)
+	if loggedInNation == nil {
+		ErrorHandler(w, r, "You must be logged in to submit orders")
+		return
+	}
+
+	routeVariables := mux.Vars(r)
+	mapID := routeVariables["id"]
+
+	err := updateMap(mapID, func(databaseMap *databasemap.DatabaseMap) error {
+		now := time.Now()
+
+		// Once the deadline has passed a player who already submitted can resolve the year for everyone
+		if !databaseMap.HasSubmittedOrders(loggedInNation.Id) || now.Before(databaseMap.OrdersDeadline()) {
+			err := databaseMap.SubmitOrders(loggedInNation.Id)
+			if err != nil {
+				return err
+			}
+		}
+
+		if !databaseMap.IsReadyToResolve(now, getAIPlayerIDs(globalAIPlayers)) {
+			return nil
+		}
+
+		return resolveYear(databaseMap, now)
+	})
+	if err != nil {
+		ErrorHandler(w, r, err.Error())
+		return
+	}
+
+	http.Redirect(w, r, "/maps/"+mapID, http.StatusSeeOther)
+}
+
+// resolveYear carries out the year's orders, simulates it and opens the next one for orders with a snapshot of how it starts
+func resolveYear(databaseMap *databasemap.DatabaseMap, now time.Time) error {
+	layout, err := getLayout(*databaseMap)
+	if err != nil {
+		return err
+	}
+
+	carryOutOrders(databaseMap, layout, globalNationStatesProvider)
+
+	err = tick(databaseMap, layout, globalNationStatesProvider, globalAIPlayers, databaseMap.NewRandomForYear())
+	if err == databasemap.MapFinishedError {
+		return err
+	}
+	if err != nil {
+		return errors.New("Failed to tick map")
+	}
+
+	err = globalRepository.PutSnapshot(databasemap.NewSnapshot(*databaseMap))
+	if err != nil && err != repository.SnapshotAlreadyExistsError {
+		return errors.New("Failed to save the history of the year")
+	}
+
+	databaseMap.StartOrderPhase(now)
+
+	return nil
+}
+
+const schedulerInterval = time.Minute
+
+// runScheduler is safe to run on several servers since updateMap only lets the first of them resolve a year
+func runScheduler(ticks <-chan time.Time) {
+	for now := range ticks {
+		tickScheduledMaps(now)
+	}
+}
+
+func tickScheduledMaps(now time.Time) {
+	mapIDs, err := globalRepository.ListMapIDsDueToTick(now)
+	if err != nil {
+		log.Println("Failed to list maps to tick:", err.Error())
+		return
+	}
+
+	for _, mapID := range mapIDs {
+		tickScheduledMap(mapID, now)
+	}
+}
+
+func tickScheduledMap(mapID string, now time.Time) {
+
+	err := updateMap(mapID, func(databaseMap *databasemap.DatabaseMap) error {
+		if !databaseMap.IsDueForScheduledTick(now) {
+			return yearAlreadyResolvedError
+		}
+
+		return resolveYear(databaseMap, now)
+	})
+	if err == yearAlreadyResolvedError {
+		return
+	}
+	if err != nil {
+		log.Println("Failed to tick map", mapID, "on schedule:", err.Error())
+	}
+}
+
+var yearAlreadyResolvedError = errors.New("The year was already resolved")
+
+func tick(residentNations *databasemap.DatabaseMap, strategicMap strategicmap.Map, nationStatesProvider nationstates_api.NationStatesProvider, aiPlayers []ai.Player, random *rand.Rand) error {
+
+	err := residentNations.CheckNotFinished()
+	if err != nil {
+		return err
+	}
+
+	residentNations.Year++
+
+	residentNations.MoveArmies(strategicMap.AreNeighbours)
+
+	err = war.ResolveBattles(residentNations, nationStatesProvider, random)
+	if err != nil {
+		return err
+	}
+
+	err = war.RollUprisings(residentNations, nationStatesProvider, random)
+	if err != nil {
+		return err
+	}
+
+	residentNations.CollectIncome()
+
+	if residentNations.CheckVictory(getAIPlayerIDs(aiPlayers)) {
+		return nil
+	}
+
+	for _, aiPlayer := range aiPlayers {
+		err := tickAIPlayer(residentNations, strategicMap, aiPlayer, nationStatesProvider)
+		if err != nil {
+			log.Println("AI player", aiPlayer.GetNation().Id, "skipped its turn on map", residentNations.ID, "after an error:", err.Error())
+		}
+	}
+
+	return nil
+}
+
+func tickAIPlayer(databaseMap *databasemap.DatabaseMap, strategicMap strategicmap.Map, aiPlayer ai.Player, nationStatesProvider nationstates_api.NationStatesProvider) error {
+
+	if !ai.IsPresent(aiPlayer, *databaseMap) {
+		return nil
+	}
+
+	aiNation := aiPlayer.GetNation()
+
+	warTargetIDs := []string{}
+	for _, warTarget := range getWarTargets(&aiNation, *databaseMap, strategicMap) {
+		warTargetIDs = append(warTargetIDs, warTarget.ID)
+	}
+
+	target, err := aiPlayer.ChooseWarTarget(*databaseMap, warTargetIDs, nationStatesProvider)
+	if err != nil {
+		return err
+	}
+
+	if len(target) != 0 {
+		err = declareWar(databaseMap, strategicMap, aiNation, target, databasemap.Conquest, nationStatesProvider)
+		if err != nil {
+			return err
+		}
+	}
+
+	return aiPlayer.CommandArmies(databaseMap, strategicMap.AreNeighbours)
+}
+
+func faviconHandler(w http.ResponseWriter, r *http.Request) {
+	http.ServeFile(w, r, "assets/uswds-2.10.0/img/flag.svg")
+}
+
+// getMapBackgroundHandler draws the board for maps played on a generated layout
+func getMapBackgroundHandler(w http.ResponseWriter, r *http.Request) {
+
+	routeVariables := mux.Vars(r)
+	mapID := routeVariables["id"]
+
+	databaseMap, err := getMap(mapID)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	layout, err := getLayout(databaseMap)
+	if err != nil || len(layout.Outlines) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", "public, max-age=86400") // a map's board never changes
+	fmt.Fprint(w, strategicmap.RenderSVG(layout))
+}
+
+func loginHandler(w http.ResponseWriter, r *http.Request) {
+
+	nationName := nationstates_api.GetCanonicalName(r.FormValue("nation_name"))
+	verificationCode := r.FormValue("verification_code")
+	if nationName == "" || verificationCode == "" {
+		ErrorHandler(w, r, "Invalid request to login. Try again.")
+		return
+	}
+
+	isVerified, err := nationstates_api.IsCorrectVerificationCode(nationName, verificationCode)
+	if err != nil {
+		ErrorHandler(w, r, "Failed to verify nation "+nationName)
+		return
+	}
+
+	log.Println(nationName, "verified:", strconv.FormatBool(isVerified))
+
+	sessionIDBytes := make([]byte, 20)
+	_, err = cryptorand.Read(sessionIDBytes)
+	if err != nil {
+		ErrorHandler(w, r, "Failed to log in. Try again.")
+		return
+	}
+	sessionIDString := base64.URLEncoding.EncodeToString(sessionIDBytes)
+
+	cookieValue := nationName + SESSION_COOKIE_SEPARATOR + sessionIDString
+	expire := time.Now().AddDate(0, 0, 1)
+	cookie := http.Cookie{Name: SESSION_COOKIE_NAME, Value: cookieValue, HttpOnly: true}
+
+	globalSessionManager.AddSession(nationName, sessionIDString, expire)
+
+	http.SetCookie(w, &cookie)
+
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func logoutHandler(w http.ResponseWriter, r *http.Request) {
+
+	loggedInNation := getLoggedInNationFromCookie(r)
+	if loggedInNation == nil {
+		ErrorHandler(w, r, "You must be logged in to log out.")
+		return
+	}
+
+	globalSessionManager.RemoveSession(loggedInNation.Id)
+
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func getWarTargets(nation *nationstates_api.Nation, databaseMap databasemap.DatabaseMap, strategicMap strategicmap.Map) []WarTarget {
+	if nation == nil {
+		return []WarTarget{}
+	}
+
+	warTargets := []WarTarget{}
+	for _, territory := range databaseMap.Cells {
+
+		canAttack, _ := canAttack(*nation, territory, databaseMap, strategicMap)
+		if canAttack {
+			warTargets = append(warTargets, WarTarget{
+				ID:   territory.ID,
+				Name: strategicmap.GetTerritoryDisplayName(territory),
+			})
+		}
+	}
+
+	return warTargets
+}
+
+func getColonyTargets(nation *nationstates_api.Nation, databaseMap databasemap.DatabaseMap, strategicMap strategicmap.Map) []ColonyTarget {
+	if nation == nil {
+		return []ColonyTarget{}
+	}
+
+	colonyTargets := []ColonyTarget{}
+	for _, territoryID := range databaseMap.GetCellIDs() {
+		territory := databaseMap.Cells[territoryID]
+		if len(territory.Resident) == 0 && databaseMap.CheckColonise(territoryID, nation.Id, strategicMap.AreNeighbours) == nil {
+			colonyTargets = append(colonyTargets, ColonyTarget{
+				ID:               territory.ID,
+				Name:             strategicmap.GetTerritoryDisplayName(territory),
+				RegimentsToAnnex: territory.RegimentsToAnnex(),
+			})
+		}
+	}
+
+	return colonyTargets
+}
+
+func getMapHandler(w http.ResponseWriter, r *http.Request) {
+
+	routeVariables := mux.Vars(r)
+	mapID := routeVariables["id"]
+
+	databaseMap, err := getMap(mapID)
+	if err != nil {
+		ErrorHandler(w, r, "Failed to retrieve map")
+		return
+	}
+
+	layout, err := getLayout(databaseMap)
+	if err != nil {
+		ErrorHandler(w, r, err.Error())
+		return
+	}
+
+	renderedMap, err := strategicmap.Render(layout, databaseMap, globalNationStatesProvider)
+	if err != nil {
+		ErrorHandler(w, r, "Failed to render map")
+		return
+	}
+
+	loggedInNation := getLoggedInNationFromCookie(r)
+
+	renderedWars, err := war.RenderWars(databaseMap.GetWars(), globalNationStatesProvider)
+	if err != nil {
+		ErrorHandler(w, r, "Failed to render wars")
+		return
+	}
+	nameBattleTerritories(renderedWars, databaseMap)
+
+	warTargets := getWarTargets(loggedInNation, databaseMap, layout)
+
+	alliances, allianceCandidates, err := getAlliances(loggedInNation, databaseMap, globalNationStatesProvider)
+	if err != nil {
+		ErrorHandler(w, r, "Failed to render alliances")
+		return
+	}
+
+	joinableWars := getJoinableWars(loggedInNation, databaseMap)
+
+	peaceNegotiations := getPeaceNegotiations(loggedInNation, databaseMap)
+
+	armies, err := renderArmies(databaseMap.GetArmies(), loggedInNation, databaseMap, layout, globalNationStatesProvider)
+	if err != nil {
+		ErrorHandler(w, r, "Failed to render armies")
+		return
+	}
+
+	orderPhase, err := getOrderPhase(loggedInNation, databaseMap, time.Now(), globalNationStatesProvider)
+	if err != nil {
+		ErrorHandler(w, r, "Failed to render orders")
+		return
+	}
+
+	droppedOrders, err := renderDroppedOrders(databaseMap, globalNationStatesProvider)
+	if err != nil {
+		ErrorHandler(w, r, "Failed to render dropped orders")
+		return
+	}
+
+	page := &MapPage{
+		Wars:                  renderedWars,
+		Map:                   renderedMap,
+		Year:                  databaseMap.Year,
+		LoggedInNation:        loggedInNation,
+		MapID:                 databaseMap.ID,
+		WarTargets:            warTargets,
+		Alliances:             alliances,
+		AllianceCandidates:    allianceCandidates,
+		JoinableWars:          joinableWars,
+		PeaceNegotiations:     peaceNegotiations,
+		MaximumCeasefireYears: databasemap.MaximumCeasefireYears,
+		Occasions:             databasemap.Occasions,
+		CombatDescription:     war.DescribeCensusWeights(war.GetCensusWeights(databaseMap)),
+		Armies:                armies,
+		OrderPhase:            orderPhase,
+		DroppedOrders:         droppedOrders,
+		IsFinished:            databaseMap.IsFinished,
+		VictoryDescription:    describeVictoryConditions(databaseMap),
+		ColonyTargets:         getColonyTargets(loggedInNation, databaseMap, layout),
+		ColonyCost:            databasemap.ColonyCost,
+	}
+
+	if loggedInNation != nil && databaseMap.HasParticipant(loggedInNation.Id) {
+		battlefield := war.NewBattlefield(databaseMap)
+		page.Commitments = &Commitments{
+			TerritoriesHeld: battlefield.TerritoriesHeld[loggedInNation.Id],
+			OngoingWars:     battlefield.OngoingWars[loggedInNation.Id],
+			Readiness:       battlefield.Readiness(loggedInNation.Id),
+		}
+
+		fundableWars := []string{}
+		for _, databaseWar := range databaseMap.GetWars() {
+			if databaseWar.IsOngoing && databaseWar.IsParticipant(loggedInNation.Id) {
+				fundableWars = append(fundableWars, databaseWar.ID)
+			}
+		}
+
+		recruitmentTerritories := []TerritoryLink{}
+		for _, territoryID := range databaseMap.GetCellIDs() {
+			territory := databaseMap.Cells[territoryID]
+			if territory.Resident == loggedInNation.Id {
+				recruitmentTerritories = append(recruitmentTerritories, TerritoryLink{ID: territory.ID, Name: strategicmap.GetTerritoryDisplayName(territory)})
+			}
+		}
+
+		page.Treasury = &Treasury{
+			RecruitmentTerritories: recruitmentTerritories,
+			Gold:                   databaseMap.GetTreasury(loggedInNation.Id),
+			Income:                 databaseMap.GetIncome(loggedInNation.Id),
+			Regiments:              databaseMap.GetRegiments(loggedInNation.Id),
+			Upkeep:                 databaseMap.GetRegiments(loggedInNation.Id) * databasemap.RegimentUpkeep,
+			RecruitCost:            databasemap.RecruitCost,
+			FundableWars:           fundableWars,
+		}
+	}
+
+	renderPage(w, "map.html", page)
+}
+
+func getWarsAPIHandler(w http.ResponseWriter, r *http.Request) {
+
+	routeVariables := mux.Vars(r)
+	mapID := routeVariables["id"]
+
+	databaseMap, err := getMap(mapID)
+	if err == repository.MapDoesntExistError {
+		http.Error(w, "Map doesn't exist", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to retrieve map", http.StatusInternalServerError)
+		return
+	}
+
+	wars := databaseMap.GetWars()
+	sort.Slice(wars, func(i, j int) bool {
+		if wars[i].StartYear != wars[j].StartYear {
+			return wars[i].StartYear < wars[j].StartYear
+		}
+		return wars[i].ID < wars[j].ID
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(wars)
+	if err != nil {
+		log.Println("Failed to encode wars:", err.Error())
+	}
+}
+
+func getMapYearHandler(w http.ResponseWriter, r *http.Request) {
+
+	routeVariables := mux.Vars(r)
+	mapID := routeVariables["id"]
+
+	year, err := strconv.Atoi(routeVariables["year"])
+	if err != nil {
+		ErrorHandler(w, r, "That isn't a valid year")
+		return
+	}
+
+	databaseMap, err := getMap(mapID)
+	if err != nil {
+		ErrorHandler(w, r, "Failed to retrieve map")
+		return
+	}
+
+	if year == databaseMap.Year {
+		http.Redirect(w, r, "/maps/"+mapID, http.StatusSeeOther)
+		return
+	}
+
+	snapshot, err := globalRepository.GetSnapshot(mapID, year)
+	if err == repository.SnapshotDoesntExistError {
+		ErrorHandler(w, r, fmt.Sprintf("There is no history for year %d", year))
+		return
+	}
+	if err != nil {
+		ErrorHandler(w, r, "Failed to retrieve map history")
+		return
+	}
+
+	snapshotMap := snapshot.ToDatabaseMap(databasemap.GetDisplayName(databaseMap))
+
+	layout, err := getLayout(databaseMap)
+	if err != nil {
+		ErrorHandler(w, r, err.Error())
+		return
+	}
+
+	renderedMap, err := strategicmap.Render(layout, snapshotMap, globalNationStatesProvider)
+	if err != nil {
+		ErrorHandler(w, r, "Failed to render map")
+		return
+	}
+
+	renderedWars, err := war.RenderWars(snapshotMap.GetWars(), globalNationStatesProvider)
+	if err != nil {
+		ErrorHandler(w, r, "Failed to render wars")
+		return
+	}
+	nameBattleTerritories(renderedWars, snapshotMap)
+
+	droppedOrders, err := renderDroppedOrders(snapshotMap, globalNationStatesProvider)
+	if err != nil {
+		ErrorHandler(w, r, "Failed to render dropped orders")
+		return
+	}
+
+	page := &MapYearPage{
+		Wars:           renderedWars,
+		DroppedOrders:  droppedOrders,
+		Map:            renderedMap,
+		Year:           year,
+		CurrentYear:    databaseMap.Year,
+		LoggedInNation: getLoggedInNationFromCookie(r),
+		MapID:          mapID,
+	}
+
+	renderPage(w, "year.html", page)
+}
+
+func getMapResultsHandler(w http.ResponseWriter, r *http.Request) {
+
+	routeVariables := mux.Vars(r)
+	mapID := routeVariables["id"]
+
+	databaseMap, err := getMap(mapID)
+	if err != nil {
+		ErrorHandler(w, r, "Failed to retrieve map")
+		return
+	}
+
+	winners := []template.HTML{}
+	for _, winnerID := range databaseMap.Winners {
+		winner, err := globalNationStatesProvider.GetNationData(winnerID)
+		if err != nil {
+			ErrorHandler(w, r, "Failed to render winners")
+			return
+		}
+
+		winners = append(winners, winner.FlagAndName())
+	}
+
+	standings := []RenderedStanding{}
+	for standingIndex, standing := range databaseMap.GetStandings() {
+		nation, err := globalNationStatesProvider.GetNationData(standing.NationID)
+		if err != nil {
+			ErrorHandler(w, r, "Failed to render standings")
+			return
+		}
+
+		standings = append(standings, RenderedStanding{
+			Rank:        standingIndex + 1,
+			Nation:      nation.FlagAndName(),
+			Territories: standing.Territories,
+			WarsWon:     standing.WarsWon,
+			Gold:        standing.Gold,
+			Regiments:   standing.Regiments,
+		})
+	}
+
+	page := &MapResultsPage{
+		LoggedInNation:     getLoggedInNationFromCookie(r),
+		MapID:              databaseMap.ID,
+		MapName:            databasemap.GetDisplayName(databaseMap),
+		Year:               databaseMap.Year,
+		IsFinished:         databaseMap.IsFinished,
+		FinishedYear:       databaseMap.FinishedYear,
+		Winners:            winners,
+		VictoryReason:      databaseMap.VictoryReason.DisplayName(),
+		VictoryDescription: describeVictoryConditions(databaseMap),
+		Standings:          standings,
+	}
+
+	renderPage(w, "results.html", page)
+}
+
+func describeVictoryConditions(databaseMap databasemap.DatabaseMap) string {
+	if len(databaseMap.VictoryConditions) == 0 {
+		return "This map has no victory conditions and carries on forever."
+	}
+
+	descriptions := []string{}
+	for _, victoryCondition := range databaseMap.VictoryConditions {
+		switch victoryCondition {
+		case databasemap.Domination:
+			descriptions = append(descriptions, "holding every territory")
+		case databasemap.TerritoryShare:
+			descriptions = append(descriptions, fmt.Sprintf("holding %d%% of the territories", databaseMap.GetVictoryTerritoryPercent()))
+		case databasemap.YearLimit:
+			descriptions = append(descriptions, fmt.Sprintf("holding the most territories in year %d", databaseMap.GetVictoryYearLimit()))
+		case databasemap.AIDefeated:
+			descriptions = append(descriptions, "driving the AI empire off the map")
+		}
+	}
+
+	return "Nations win by " + strings.Join(descriptions, " or ") + "."
+}
+
+type MapResultsPage struct {
+	LoggedInNation     *nationstates_api.Nation
+	MapID              string
+	MapName            string
+	Year               int
+	IsFinished         bool
+	FinishedYear       int
+	Winners            []template.HTML
+	VictoryReason      string
+	VictoryDescription string
+	Standings          []RenderedStanding
+}
+
+type RenderedStanding struct {
+	Rank        int
+	Nation      template.HTML
+	Territories int
+	WarsWon     int
+	Gold        int
+	Regiments   int
+}
+
+type MapYearPage struct {
+	Wars           []war.RenderedWar
+	DroppedOrders  []RenderedDroppedOrder
+	Map            strategicmap.RenderedMap
+	Year           int
+	CurrentYear    int
+	LoggedInNation *nationstates_api.Nation
+	MapID          string
+}
+
+func (page MapYearPage) PreviousYear() int {
+	return page.Year - 1
+}
+
+func (page MapYearPage) NextYear() int {
+	return page.Year + 1
+}
+
+type WarTarget struct {
+	ID   string
+	Name string
+}
+
+type MapPage struct {
+	Wars                  []war.RenderedWar
+	Map                   strategicmap.RenderedMap
+	Year                  int
+	LoggedInNation        *nationstates_api.Nation
+	MapID                 string
+	WarTargets            []WarTarget
+	Alliances             []RenderedAlliance
+	AllianceCandidates    []AllianceCandidate
+	JoinableWars          []JoinableWar
+	PeaceNegotiations     []PeaceNegotiation
+	MaximumCeasefireYears int
+	Occasions             []databasemap.Occasion
+	CombatDescription     string
+	Commitments           *Commitments
+	Treasury              *Treasury
+	Armies                []RenderedArmy
+	OrderPhase            OrderPhase
+	DroppedOrders         []RenderedDroppedOrder
+	IsFinished            bool
+	VictoryDescription    string
+	ColonyTargets         []ColonyTarget
+	ColonyCost            int
+}
+
+type ColonyTarget struct {
+	ID               string
+	Name             string
+	RegimentsToAnnex int
+}
+
+type OrderPhase struct {
+	Cadence         string
+	Deadline        string
+	IsOverdue       bool
+	AwaitingNations []template.HTML
+	IsPlayer        bool
+	HasSubmitted    bool
+	GivenOrders     []template.HTML
+}
+
+type RenderedDroppedOrder struct {
+	Nation      template.HTML
+	Description template.HTML
+	Reason      string
+}
+
+type Treasury struct {
+	Gold                   int
+	Income                 int
+	Regiments              int
+	Upkeep                 int
+	RecruitCost            int
+	FundableWars           []string
+	RecruitmentTerritories []TerritoryLink
+}
+
+type RenderedArmy struct {
+	ID           string
+	Owner        template.HTML
+	Territory    TerritoryLink
+	Regiments    int
+	Destination  string
+	IsOwn        bool
+	Destinations []TerritoryLink
+}
+
+func getOrderPhase(loggedInNation *nationstates_api.Nation, databaseMap databasemap.DatabaseMap, now time.Time, nationStatesProvider nationstates_api.NationStatesProvider) (OrderPhase, error) {
+
+	awaitingNations := []template.HTML{}
+	for _, nationID := range databaseMap.AwaitingOrdersFrom(getAIPlayerIDs(globalAIPlayers)) {
+		nation, err := nationStatesProvider.GetNationData(nationID)
+		if err != nil {
+			return OrderPhase{}, err
+		}
+
+		awaitingNations = append(awaitingNations, nation.FlagAndName())
+	}
+
+	orderPhase := OrderPhase{
+		Cadence:         databaseMap.TickCadence.DisplayName(),
+		Deadline:        databaseMap.OrdersDeadline().UTC().Format("2 January 2006 15:04 MST"),
+		IsOverdue:       !now.Before(databaseMap.OrdersDeadline()),
+		AwaitingNations: awaitingNations,
 	}
 
-	err = globalMaps.PutMap(databaseMap)
-	if err != nil {
-		ErrorHandler(w, r, "Failed to save map")
-		return
+	if loggedInNation != nil {
+		orderPhase.IsPlayer = databaseMap.IsPlayer(loggedInNation.Id)
+		orderPhase.HasSubmitted = databaseMap.HasSubmittedOrders(loggedInNation.Id)
+
+		orderPhase.GivenOrders = []template.HTML{}
+		for _, order := range databaseMap.GetOrdersOf(loggedInNation.Id) {
+			description, err := describeOrder(order, databaseMap, nationStatesProvider)
+			if err != nil {
+				return OrderPhase{}, err
+			}
+
+			orderPhase.GivenOrders = append(orderPhase.GivenOrders, description)
+		}
 	}
 
-	http.Redirect(w, r, "/maps/"+mapID, http.StatusSeeOther)
+	return orderPhase, nil
 }
 
-func tickHandler(w http.ResponseWriter, r *http.Request) {
+func renderDroppedOrders(databaseMap databasemap.DatabaseMap, nationStatesProvider nationstates_api.NationStatesProvider) ([]RenderedDroppedOrder, error) {
+	renderedDroppedOrders := []RenderedDroppedOrder{}
+	for _, droppedOrder := range databaseMap.DroppedOrders {
+		nation, err := nationStatesProvider.GetNationData(droppedOrder.Order.Nation)
+		if err != nil {
+			return []RenderedDroppedOrder{}, err
+		}
 
-	routeVariables := mux.Vars(r)
-	mapID := routeVariables["id"]
+		description, err := describeOrder(droppedOrder.Order, databaseMap, nationStatesProvider)
+		if err != nil {
+			return []RenderedDroppedOrder{}, err
+		}
 
-	databaseMap, err := globalMaps.GetMap(mapID)
-	if err != nil {
-		ErrorHandler(w, r, "Failed to get map")
-		return
+		renderedDroppedOrders = append(renderedDroppedOrders, RenderedDroppedOrder{
+			Nation:      nation.FlagAndName(),
+			Description: description,
+			Reason:      droppedOrder.Reason,
+		})
 	}
+	return renderedDroppedOrders, nil
+}
 
-	err = tick(&databaseMap, globalNationStatesProvider)
-	if err != nil {
-		ErrorHandler(w, r, "Failed to tick map")
-		return
+func describeOrder(order databasemap.DatabaseOrder, databaseMap databasemap.DatabaseMap, nationStatesProvider nationstates_api.NationStatesProvider) (template.HTML, error) {
+	territoryName := template.HTMLEscapeString(strategicmap.GetTerritoryDisplayName(databaseMap.Cells[order.TerritoryID]))
+	warName := template.HTMLEscapeString(order.WarID)
+
+	otherNation := template.HTML("")
+	if len(order.OtherNation) != 0 {
+		nation, err := nationStatesProvider.GetNationData(order.OtherNation)
+		if err != nil {
+			return "", err
+		}
+		otherNation = nation.FlagAndName()
 	}
 
-	err = globalMaps.PutMap(databaseMap)
-	if err != nil {
-		ErrorHandler(w, r, "Failed to save map")
-		return
+	switch order.Kind {
+	case databasemap.DeclareWarOrder:
+		return template.HTML(fmt.Sprintf("Declare war for %s (%s)", territoryName, template.HTMLEscapeString(order.Occasion.DisplayName()))), nil
+	case databasemap.JoinWarOrder:
+		if order.Side == databasemap.AttackingSide {
+			return template.HTML(fmt.Sprintf("Join %s with the attackers", warName)), nil
+		}
+		return template.HTML(fmt.Sprintf("Join %s with the defenders", warName)), nil
+	case databasemap.FundWarOrder:
+		return template.HTML(fmt.Sprintf("Spend %d gold on %s", order.Amount, warName)), nil
+	case databasemap.RecruitOrder:
+		return template.HTML(fmt.Sprintf("Recruit a regiment in %s", territoryName)), nil
+	case databasemap.MoveArmyOrder:
+		if len(order.TerritoryID) == 0 {
+			return "Hold an army where it is", nil
+		}
+		return template.HTML(fmt.Sprintf("March an army to %s", territoryName)), nil
+	case databasemap.FortifyOrder:
+		return template.HTML(fmt.Sprintf("Fortify %s", territoryName)), nil
+	case databasemap.ColoniseOrder:
+		return template.HTML(fmt.Sprintf("Colonise %s", territoryName)), nil
+	case databasemap.OfferPeaceOrder:
+		offer := databasemap.DatabasePeaceOffer{Terms: order.Terms, CeasefireYears: order.CeasefireYears}
+		return template.HTML(fmt.Sprintf("Offer peace in %s: %s", warName, template.HTMLEscapeString(offer.Description()))), nil
+	case databasemap.AcceptPeaceOrder:
+		return template.HTML(fmt.Sprintf("Accept the peace terms in %s", warName)), nil
+	case databasemap.RejectPeaceOrder:
+		return template.HTML(fmt.Sprintf("Reject the peace terms in %s", warName)), nil
+	case databasemap.ProposeAllianceOrder:
+		return template.HTML(fmt.Sprintf("Propose an alliance to %s", otherNation)), nil
+	case databasemap.AcceptAllianceOrder:
+		return template.HTML(fmt.Sprintf("Accept the alliance with %s", otherNation)), nil
+	case databasemap.BreakAllianceOrder:
+		return template.HTML(fmt.Sprintf("Leave the alliance with %s", otherNation)), nil
 	}
 
-	http.Redirect(w, r, "/maps/"+mapID, http.StatusSeeOther)
+	return template.HTML(template.HTMLEscapeString(string(order.Kind))), nil
 }
 
-func tick(residentNations *databasemap.DatabaseMap, nationStatesProvider nationstates_api.NationStatesProvider) error {
+func renderArmies(armies []databasemap.DatabaseArmy, loggedInNation *nationstates_api.Nation, databaseMap databasemap.DatabaseMap, strategicMap strategicmap.Map, nationStatesProvider nationstates_api.NationStatesProvider) ([]RenderedArmy, error) {
+	renderedArmies := []RenderedArmy{}
+	for _, army := range armies {
+		owner, err := nationStatesProvider.GetNationData(army.Owner)
+		if err != nil {
+			return []RenderedArmy{}, err
+		}
 
-	residentNations.Year++
+		renderedArmy := RenderedArmy{
+			ID:           army.ID,
+			Owner:        owner.FlagAndName(),
+			Territory:    TerritoryLink{ID: army.TerritoryID, Name: strategicmap.GetTerritoryDisplayName(databaseMap.Cells[army.TerritoryID])},
+			Regiments:    army.Regiments,
+			IsOwn:        loggedInNation != nil && army.Owner == loggedInNation.Id,
+			Destinations: []TerritoryLink{},
+		}
 
-	databaseWars := residentNations.GetWars()
+		destination := army.Destination
+		if renderedArmy.IsOwn {
+			for _, order := range databaseMap.GetOrdersOf(loggedInNation.Id) {
+				if order.Kind == databasemap.MoveArmyOrder && order.ArmyID == army.ID {
+					destination = order.TerritoryID
+				}
+			}
+		}
 
-	for warIndex := range databaseWars {
-		didFinish, err := war.Tick(&databaseWars[warIndex], nationStatesProvider, residentNations.Year)
-		if err != nil {
-			return err
+		if len(destination) != 0 {
+			renderedArmy.Destination = strategicmap.GetTerritoryDisplayName(databaseMap.Cells[destination])
 		}
 
-		if didFinish {
+		if renderedArmy.IsOwn {
+			for _, neighbourID := range strategicMap.Neighbours(army.TerritoryID) {
+				neighbour, doesNeighbourExist := databaseMap.Cells[neighbourID]
+				if doesNeighbourExist {
+					renderedArmy.Destinations = append(renderedArmy.Destinations, TerritoryLink{ID: neighbour.ID, Name: strategicmap.GetTerritoryDisplayName(neighbour)})
+				}
+			}
+		}
 
-			advantageID := war.WarAdvantage(databaseWars[warIndex])
+		renderedArmies = append(renderedArmies, renderedArmy)
+	}
+	return renderedArmies, nil
+}
 
-			if advantageID == nil {
-				return errors.New("Nil war winner ID")
+// nameBattleTerritories shows the names players gave territories in the battle logs
+func nameBattleTerritories(renderedWars []war.RenderedWar, databaseMap databasemap.DatabaseMap) {
+	for _, renderedWar := range renderedWars {
+		for battleIndex, battle := range renderedWar.Battles {
+			territory, doesTerritoryExist := databaseMap.Cells[battle.Territory]
+			if doesTerritoryExist {
+				renderedWar.Battles[battleIndex].Territory = strategicmap.GetTerritoryDisplayName(territory)
 			}
-
-			residentNations.SetResident(databaseWars[warIndex].TerritoryName, *advantageID)
 		}
 	}
-
-	residentNations.PutWars(databaseWars)
-
-	return nil
 }
 
-func faviconHandler(w http.ResponseWriter, r *http.Request) {
-	http.ServeFile(w, r, "assets/uswds-2.10.0/img/flag.svg")
+type Commitments struct {
+	TerritoriesHeld int
+	OngoingWars     int
+	Readiness       int
 }
 
-func loginHandler(w http.ResponseWriter, r *http.Request) {
+type PeaceNegotiation struct {
+	WarID      string
+	Offer      string // Empty when no terms are on the table
+	IsIncoming bool
+}
 
-	nationName := nationstates_api.GetCanonicalName(r.FormValue("nation_name"))
-	verificationCode := r.FormValue("verification_code")
-	if nationName == "" || verificationCode == "" {
-		ErrorHandler(w, r, "Invalid request to login. Try again.")
-		return
+// getPeaceNegotiations lists the ongoing wars the logged in nation leads and any terms on the table in each
+func getPeaceNegotiations(nation *nationstates_api.Nation, databaseMap databasemap.DatabaseMap) []PeaceNegotiation {
+	if nation == nil {
+		return []PeaceNegotiation{}
 	}
 
-	isVerified, err := nationstates_api.IsCorrectVerificationCode(nationName, verificationCode)
-	if err != nil {
-		ErrorHandler(w, r, "Failed to verify nation "+nationName)
-		return
-	}
+	peaceNegotiations := []PeaceNegotiation{}
+	for _, databaseWar := range databaseMap.GetWars() {
+		if !databaseWar.IsOngoing || (databaseWar.Attacker != nation.Id && databaseWar.Defender != nation.Id) {
+			continue
+		}
 
-	log.Println(nationName, "verified:", strconv.FormatBool(isVerified))
+		peaceNegotiation := PeaceNegotiation{WarID: databaseWar.ID}
 
-	sessionIDBytes := sha1.Sum([]byte(nationName + strconv.Itoa(rand.Int())))
-	sessionIDString := base64.URLEncoding.EncodeToString(sessionIDBytes[:]) // [:] converts slice to array
+		offer, doesOfferExist := databaseMap.PeaceOffers[databaseWar.ID]
+		if doesOfferExist {
+			peaceNegotiation.Offer = offer.Description()
+			peaceNegotiation.IsIncoming = offer.To == nation.Id
+		}
 
-	cookieValue := nationName + SESSION_COOKIE_SEPARATOR + sessionIDString
-	expire := time.Now().AddDate(0, 0, 1)
-	cookie := http.Cookie{Name: SESSION_COOKIE_NAME, Value: cookieValue, HttpOnly: true}
+		peaceNegotiations = append(peaceNegotiations, peaceNegotiation)
+	}
 
-	globalSessionManager.AddSession(nationName, sessionIDString, expire)
+	return peaceNegotiations
+}
 
-	http.SetCookie(w, &cookie)
+type RenderedAlliance struct {
+	NationID   string
+	Nation     template.HTML
+	IsAccepted bool
+	IsIncoming bool
+}
 
-	http.Redirect(w, r, "/", http.StatusSeeOther)
+type AllianceCandidate struct {
+	ID   string
+	Name string
 }
 
-func logoutHandler(w http.ResponseWriter, r *http.Request) {
+type JoinableWar struct {
+	ID   string
+	Side string
+}
 
-	loggedInNation := getLoggedInNationFromCookie(r)
-	if loggedInNation == nil {
-		ErrorHandler(w, r, "You must be logged in to log out.")
-		return
+// getAlliances returns the logged in nation's alliances and the participants it could propose one to
+func getAlliances(nation *nationstates_api.Nation, databaseMap databasemap.DatabaseMap, nationStatesProvider nationstates_api.NationStatesProvider) ([]RenderedAlliance, []AllianceCandidate, error) {
+	if nation == nil || !databaseMap.HasParticipant(nation.Id) {
+		return []RenderedAlliance{}, []AllianceCandidate{}, nil
 	}
 
-	globalSessionManager.RemoveSession(loggedInNation.Id)
+	alliances := []RenderedAlliance{}
+	for _, alliance := range databaseMap.GetAlliances() {
+		if !alliance.Includes(nation.Id) {
+			continue
+		}
 
-	http.Redirect(w, r, "/", http.StatusSeeOther)
-}
+		otherNation, err := nationStatesProvider.GetNationData(alliance.Other(nation.Id))
+		if err != nil {
+			return []RenderedAlliance{}, []AllianceCandidate{}, err
+		}
 
-func getWarTargets(nation *nationstates_api.Nation, databaseMap databasemap.DatabaseMap) []WarTarget {
-	if nation == nil {
-		return []WarTarget{}
+		alliances = append(alliances, RenderedAlliance{
+			NationID:   otherNation.Id,
+			Nation:     otherNation.FlagAndName(),
+			IsAccepted: alliance.IsAccepted,
+			IsIncoming: alliance.Invitee == nation.Id,
+		})
 	}
 
-	warTargets := []WarTarget{}
-	for _, territory := range databaseMap.Cells {
+	allianceCandidates := []AllianceCandidate{}
+	for _, participantID := range databaseMap.Participants {
+		_, hasAlliance := databaseMap.Alliances[databasemap.AllianceID(nation.Id, participantID)]
+		if participantID == nation.Id || hasAlliance || isAIPlayer(participantID, globalAIPlayers) {
+			continue
+		}
 
-		canAttack, _ := canAttack(*nation, territory, databaseMap.GetWars())
-		if canAttack {
-			warTargets = append(warTargets, WarTarget{
-				ID:   territory.ID,
-				Name: strategicmap.GetTerritoryDisplayName(territory),
-			})
+		participant, err := nationStatesProvider.GetNationData(participantID)
+		if err != nil {
+			return []RenderedAlliance{}, []AllianceCandidate{}, err
 		}
+
+		allianceCandidates = append(allianceCandidates, AllianceCandidate{ID: participant.Id, Name: participant.Name})
 	}
 
-	return warTargets
+	return alliances, allianceCandidates, nil
 }
 
-func getMapHandler(w http.ResponseWriter, r *http.Request) {
-
-	routeVariables := mux.Vars(r)
-	mapID := routeVariables["id"]
-
-	databaseMap, err := globalMaps.GetMap(mapID)
-	if err != nil {
-		ErrorHandler(w, r, "Failed to retrieve map")
-		return
+func getJoinableWars(nation *nationstates_api.Nation, databaseMap databasemap.DatabaseMap) []JoinableWar {
+	if nation == nil {
+		return []JoinableWar{}
 	}
 
-	renderedMap, err := strategicmap.Render(globalStrategicMap, databaseMap, globalNationStatesProvider)
-	if err != nil {
-		ErrorHandler(w, r, "Failed to render map")
-		return
-	}
+	joinableWars := []JoinableWar{}
+	for _, databaseWar := range databaseMap.GetWars() {
+		if !databaseWar.IsOngoing || databaseWar.IsParticipant(nation.Id) {
+			continue
+		}
 
-	loggedInNation := getLoggedInNationFromCookie(r)
+		if databaseMap.AreAllied(nation.Id, databaseWar.Attacker) {
+			joinableWars = append(joinableWars, JoinableWar{ID: databaseWar.ID, Side: databasemap.AttackingSide})
+		}
 
-	renderedWars, err := war.RenderWars(databaseMap.GetWars(), globalNationStatesProvider)
-	if err != nil {
-		ErrorHandler(w, r, "Failed to render wars")
-		return
+		if databaseMap.AreAllied(nation.Id, databaseWar.Defender) {
+			joinableWars = append(joinableWars, JoinableWar{ID: databaseWar.ID, Side: databasemap.DefendingSide})
+		}
 	}
 
-	warTargets := getWarTargets(loggedInNation, databaseMap)
-
-	page := &MapPage{Wars: renderedWars, Map: renderedMap, Year: databaseMap.Year, LoggedInNation: loggedInNation, MapID: databaseMap.ID, WarTargets: warTargets}
-
-	renderPage(w, "map.html", page)
-}
-
-type WarTarget struct {
-	ID   string
-	Name string
-}
-
-type MapPage struct {
-	Wars           []war.RenderedWar
-	Map            strategicmap.RenderedMap
-	Year           int
-	LoggedInNation *nationstates_api.Nation
-	MapID          string
-	WarTargets     []WarTarget
+	return joinableWars
 }
 
 func getTerritoryHandler(w http.ResponseWriter, r *http.Request) {
@@ -418,7 +1692,7 @@ func getTerritoryHandler(w http.ResponseWriter, r *http.Request) {
 	routeVariables := mux.Vars(r)
 	mapID := routeVariables["map_id"]
 
-	databaseMap, err := globalMaps.GetMap(mapID)
+	databaseMap, err := getMap(mapID)
 	if err != nil {
 		ErrorHandler(w, r, "Failed to retrieve map")
 		return
@@ -432,34 +1706,101 @@ func getTerritoryHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resident, err := globalNationStatesProvider.GetNationData(territory.Resident)
-	if err != nil || resident == nil {
-		ErrorHandler(w, r, "Failed to get resident nation data")
+	resident := &nationstates_api.Nation{}
+	if len(territory.Resident) != 0 {
+		resident, err = globalNationStatesProvider.GetNationData(territory.Resident)
+		if err != nil || resident == nil {
+			ErrorHandler(w, r, "Failed to get resident nation data")
+			return
+		}
+	}
+
+	var originalResident *nationstates_api.Nation
+	if territory.IsOccupied() {
+		originalResident, err = globalNationStatesProvider.GetNationData(territory.OriginalResident)
+		if err != nil {
+			ErrorHandler(w, r, "Failed to get original resident nation data")
+			return
+		}
+	}
+
+	layout, err := getLayout(databaseMap)
+	if err != nil {
+		ErrorHandler(w, r, err.Error())
 		return
 	}
 
 	loggedInNation := getLoggedInNationFromCookie(r)
 
+	canColonise := false
+	if loggedInNation != nil {
+		canColonise = databaseMap.CheckColonise(territoryID, loggedInNation.Id, layout.AreNeighbours) == nil
+	}
+
 	territoryName := strategicmap.GetTerritoryDisplayName(territory)
 
+	neighbours := []TerritoryLink{}
+	for _, neighbourID := range layout.Neighbours(territoryID) {
+		neighbour, doesNeighbourExist := databaseMap.Cells[neighbourID]
+		if doesNeighbourExist {
+			neighbours = append(neighbours, TerritoryLink{
+				ID:   neighbour.ID,
+				Name: strategicmap.GetTerritoryDisplayName(neighbour),
+			})
+		}
+	}
+
 	page := &TerritoryPage{
-		LoggedInNation: loggedInNation,
-		Resident:       *resident,
-		MapName:        databasemap.GetDisplayName(databaseMap),
-		MapID:          databaseMap.ID,
-		TerritoryName:  territoryName,
-		TerritoryID:    territoryID}
+		LoggedInNation:            loggedInNation,
+		Resident:                  *resident,
+		MapName:                   databasemap.GetDisplayName(databaseMap),
+		MapID:                     databaseMap.ID,
+		TerritoryName:             territoryName,
+		TerritoryID:               territoryID,
+		Neighbours:                neighbours,
+		Terrain:                   territory.Terrain.DisplayName(),
+		FortificationLevel:        territory.FortificationLevel,
+		MaximumFortificationLevel: databasemap.MaximumFortificationLevel,
+		DefenseBonusPercent:       war.DefenseBonusPercent(territory),
+		Income:                    territory.YearlyIncome(),
+		FortifyCost:               databasemap.FortifyCost,
+		OriginalResident:          originalResident,
+		HeldSinceYear:             territory.HeldSinceYear,
+		UprisingPercent:           war.UprisingPercent(territory, *resident, databaseMap.RegimentsAt(territoryID, []string{territory.Resident}), databaseMap.Year+1),
+		NeutralStrength:           territory.NeutralStrength,
+		RegimentsToAnnex:          territory.RegimentsToAnnex(),
+		CanColonise:               canColonise,
+		ColonyCost:                databasemap.ColonyCost}
 
 	renderPage(w, "territory.html", page)
 }
 
 type TerritoryPage struct {
-	LoggedInNation *nationstates_api.Nation
-	Resident       nationstates_api.Nation
-	MapName        string
-	MapID          string
-	TerritoryName  string
-	TerritoryID    string
+	LoggedInNation            *nationstates_api.Nation
+	Resident                  nationstates_api.Nation
+	MapName                   string
+	MapID                     string
+	TerritoryName             string
+	TerritoryID               string
+	Neighbours                []TerritoryLink
+	Terrain                   string
+	FortificationLevel        int
+	MaximumFortificationLevel int
+	DefenseBonusPercent       int
+	Income                    int
+	FortifyCost               int
+	OriginalResident          *nationstates_api.Nation // Only set while the territory is occupied
+	HeldSinceYear             int
+	UprisingPercent           int // The chance of an uprising when the year ends
+	NeutralStrength           int
+	RegimentsToAnnex          int
+	CanColonise               bool
+	ColonyCost                int
+}
+
+type TerritoryLink struct {
+	ID   string
+	Name string
 }
 
 func contains(list []string, valueToLookFor string) bool {
@@ -501,21 +1842,142 @@ func postMapHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if r.FormValue("include_ai_empire") == "on" && !contains(participatingNationNamesCanonical, ai.EmpireID) {
+		participatingNationNamesCanonical = append(participatingNationNamesCanonical, ai.EmpireID)
+	}
+
 	for _, nationName := range participatingNationNamesCanonical {
-		nation, err := nationstates_api.GetNationData(nationName)
+		nation, err := globalNationStatesProvider.GetNationData(nationName)
 		if nation == nil || err != nil {
 			ErrorHandler(w, r, "Could not find nation '"+nationName+"'. Check for typing or spelling errors such as extra spaces and try again.")
 			return
 		}
 	}
 
-	databaseMap, err := strategicmap.MakeNewRandomMap(globalStrategicMap, participatingNationNamesCanonical, name)
+	combatModel := war.MilitaryCombatModel
+	if len(r.FormValue("combat_model")) != 0 {
+		var doesCombatModelExist bool
+		combatModel, doesCombatModelExist = war.FindCombatModel(r.FormValue("combat_model"))
+		if !doesCombatModelExist {
+			ErrorHandler(w, r, "You didn't choose a valid combat model.")
+			return
+		}
+	}
+
+	seed := time.Now().UnixNano()
+
+	layout := strategicmap.StaticMap
+	if r.FormValue("layout") == "generated" {
+		generatedTerritories := strategicmap.DefaultGeneratedTerritories
+		if len(r.FormValue("generated_territories")) != 0 {
+			var err error
+			generatedTerritories, err = strconv.Atoi(r.FormValue("generated_territories"))
+			if err != nil {
+				ErrorHandler(w, r, "You didn't choose a valid number of territories to generate.")
+				return
+			}
+		}
+
+		var err error
+		layout, err = strategicmap.GenerateLayout(generatedTerritories, seed)
+		if err != nil {
+			ErrorHandler(w, r, err.Error())
+			return
+		}
+	} else if len(r.FormValue("layout")) != 0 {
+		var doesLayoutExist bool
+		layout, doesLayoutExist = strategicmap.FindLayout(globalLayouts, r.FormValue("layout"))
+		if !doesLayoutExist {
+			ErrorHandler(w, r, "You didn't choose a valid map layout.")
+			return
+		}
+	}
+
+	orderPhaseHours := databasemap.DefaultOrderPhaseHours
+	if len(r.FormValue("order_phase_hours")) != 0 {
+		var err error
+		orderPhaseHours, err = strconv.Atoi(r.FormValue("order_phase_hours"))
+		if err != nil || orderPhaseHours < 1 || orderPhaseHours > databasemap.MaximumOrderPhaseHours {
+			ErrorHandler(w, r, fmt.Sprintf("Players must have between 1 and %d hours to give their orders each year.", databasemap.MaximumOrderPhaseHours))
+			return
+		}
+	}
+
+	tickCadence := databasemap.ManualCadence
+	if len(r.FormValue("tick_cadence")) != 0 {
+		tickCadence = databasemap.Cadence(r.FormValue("tick_cadence"))
+		if !tickCadence.IsValid() {
+			ErrorHandler(w, r, "You didn't choose a valid schedule for the map.")
+			return
+		}
+	}
+
+	victoryConditions := []databasemap.VictoryCondition{}
+	for _, victoryConditionValue := range r.Form["victory_conditions"] {
+		victoryCondition := databasemap.VictoryCondition(victoryConditionValue)
+		if !victoryCondition.IsValid() {
+			ErrorHandler(w, r, "You didn't choose a valid way to win the map.")
+			return
+		}
+		victoryConditions = append(victoryConditions, victoryCondition)
+	}
+
+	if len(victoryConditions) == 0 {
+		ErrorHandler(w, r, "Choose at least one way to win the map.")
+		return
+	}
+
+	victoryTerritoryPercent := databasemap.DefaultVictoryTerritoryPercent
+	if len(r.FormValue("victory_territory_percent")) != 0 {
+		var err error
+		victoryTerritoryPercent, err = strconv.Atoi(r.FormValue("victory_territory_percent"))
+		if err != nil || victoryTerritoryPercent < databasemap.MinimumVictoryTerritoryPercent || victoryTerritoryPercent > 100 {
+			ErrorHandler(w, r, fmt.Sprintf("The share of territory needed to win must be between %d%% and 100%%.", databasemap.MinimumVictoryTerritoryPercent))
+			return
+		}
+	}
+
+	victoryYearLimit := databasemap.DefaultVictoryYearLimit
+	if len(r.FormValue("victory_year_limit")) != 0 {
+		var err error
+		victoryYearLimit, err = strconv.Atoi(r.FormValue("victory_year_limit"))
+		if err != nil || victoryYearLimit < 1 {
+			ErrorHandler(w, r, "The year limit must be at least 1.")
+			return
+		}
+	}
+
+	neutralTerritories := 0
+	if len(r.FormValue("neutral_territories")) != 0 {
+		var err error
+		neutralTerritories, err = strconv.Atoi(r.FormValue("neutral_territories"))
+		if err != nil || neutralTerritories < 0 {
+			ErrorHandler(w, r, "You didn't choose a valid number of neutral territories.")
+			return
+		}
+	}
+
+	databaseMap, err := strategicmap.MakeNewRandomMapWithNeutralTerritories(layout, participatingNationNamesCanonical, neutralTerritories, name, seed)
 	if err != nil {
 		ErrorHandler(w, r, err.Error())
 		return
 	}
 
-	err = dynamodbwrapper.PutMap(databaseMap)
+	databaseMap.CensusWeights = combatModel.Weights
+	databaseMap.OrderPhaseHours = orderPhaseHours
+	databaseMap.TickCadence = tickCadence
+	databaseMap.VictoryConditions = victoryConditions
+	databaseMap.VictoryTerritoryPercent = victoryTerritoryPercent
+	databaseMap.VictoryYearLimit = victoryYearLimit
+	databaseMap.StartOrderPhase(time.Now())
+
+	err = globalRepository.PutSnapshot(databasemap.NewSnapshot(databaseMap))
+	if err != nil {
+		ErrorHandler(w, r, "Failed to save map. Try again later.")
+		return
+	}
+
+	err = globalRepository.PutMap(databaseMap)
 	if err != nil {
 		ErrorHandler(w, r, "Failed to save map. Try again later.")
 		return
@@ -558,29 +2020,74 @@ func renameTerritoryHandler(w http.ResponseWriter, r *http.Request) {
 
 	routeVariables := mux.Vars(r)
 	mapID := routeVariables["map_id"]
-	databaseMap, err := globalMaps.GetMap(mapID)
+	territoryID := routeVariables["territory_id"]
+
+	err := updateMap(mapID, func(databaseMap *databasemap.DatabaseMap) error {
+		territory, doesTerritoryExist := databaseMap.Cells[territoryID]
+		if !doesTerritoryExist {
+			return errors.New("Territory does not exist")
+		}
+
+		if territory.Resident != loggedInNation.Id {
+			return errors.New("You must control a territory in order to rename it.")
+		}
+
+		territory.Name = name
+
+		databaseMap.Cells[territoryID] = territory
+
+		return nil
+	})
 	if err != nil {
-		ErrorHandler(w, r, "Failed to get map")
+		ErrorHandler(w, r, err.Error())
 		return
 	}
 
-	territoryID := routeVariables["territory_id"]
-	territory, doesTerritoryExist := databaseMap.Cells[territoryID]
-	if !doesTerritoryExist {
-		ErrorHandler(w, r, "Territory does not exist")
+	http.Redirect(w, r, "/maps/"+mapID+"/territories/"+territoryID, http.StatusSeeOther)
+}
+
+func coloniseTerritoryHandler(w http.ResponseWriter, r *http.Request) {
+
+	loggedInNation := getLoggedInNationFromCookie(r)
+	if loggedInNation == nil {
+		ErrorHandler(w, r, "You must be logged in to colonise a territory.")
 		return
 	}
 
-	if territory.Resident != loggedInNation.Id {
-		ErrorHandler(w, r, "You must control a territory in order to rename it.")
+	routeVariables := mux.Vars(r)
+	mapID := routeVariables["map_id"]
+	territoryID := routeVariables["territory_id"]
+
+	err := updateMap(mapID, func(databaseMap *databasemap.DatabaseMap) error {
+		return giveOrder(databaseMap, databasemap.DatabaseOrder{Nation: loggedInNation.Id, Kind: databasemap.ColoniseOrder, TerritoryID: territoryID})
+	})
+	if err != nil {
+		ErrorHandler(w, r, err.Error())
 		return
 	}
 
-	territory.Name = name
+	http.Redirect(w, r, "/maps/"+mapID+"/territories/"+territoryID, http.StatusSeeOther)
+}
+
+func fortifyTerritoryHandler(w http.ResponseWriter, r *http.Request) {
+
+	loggedInNation := getLoggedInNationFromCookie(r)
+	if loggedInNation == nil {
+		ErrorHandler(w, r, "You must be logged in to fortify a territory.")
+		return
+	}
 
-	databaseMap.Cells[territoryID] = territory
+	routeVariables := mux.Vars(r)
+	mapID := routeVariables["map_id"]
+	territoryID := routeVariables["territory_id"]
 
-	err = globalMaps.PutMap(databaseMap)
+	err := updateMap(mapID, func(databaseMap *databasemap.DatabaseMap) error {
+		return giveOrder(databaseMap, databasemap.DatabaseOrder{Nation: loggedInNation.Id, Kind: databasemap.FortifyOrder, TerritoryID: territoryID})
+	})
+	if err != nil {
+		ErrorHandler(w, r, err.Error())
+		return
+	}
 
 	http.Redirect(w, r, "/maps/"+mapID+"/territories/"+territoryID, http.StatusSeeOther)
 }
@@ -592,9 +2099,18 @@ func main() {
 		log.Println("Failed to load .env file:", err.Error())
 	}
 
-	dynamodbwrapper.Initialize()
+	globalRepository, err = repository.New(os.Getenv("STORAGE_BACKEND"))
+	if err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+
+	globalLayouts, err = strategicmap.LoadLayouts("layouts", []strategicmap.Map{strategicmap.StaticMap})
+	if err != nil {
+		log.Fatalf("Failed to load map layouts: %v", err)
+	}
 
-	rand.Seed(time.Now().UnixNano())
+	sessionManager := session.NewSessionManagerRepository(globalRepository)
+	globalSessionManager = &sessionManager
 
 	mux := mux.NewRouter()
 
@@ -606,12 +2122,30 @@ func main() {
 	mux.HandleFunc("/login", loginHandler).Methods("POST")
 	mux.HandleFunc("/logout", logoutHandler).Methods("POST")
 	mux.HandleFunc("/maps/{id}", getMapHandler).Methods("GET")
+	mux.HandleFunc("/maps/{id}/years/{year}", getMapYearHandler).Methods("GET")
+	mux.HandleFunc("/maps/{id}/results", getMapResultsHandler).Methods("GET")
+	mux.HandleFunc("/maps/{id}/background.svg", getMapBackgroundHandler).Methods("GET")
+	mux.HandleFunc("/api/maps/{id}/wars", getWarsAPIHandler).Methods("GET")
 	mux.HandleFunc("/maps/{map_id}/territories/{territory_id}", getTerritoryHandler).Methods("GET")
 	mux.HandleFunc("/maps/{map_id}/territories/{territory_id}/name", renameTerritoryHandler).Methods("POST")
+	mux.HandleFunc("/maps/{map_id}/territories/{territory_id}/fortifications", fortifyTerritoryHandler).Methods("POST")
+	mux.HandleFunc("/maps/{map_id}/territories/{territory_id}/colony", coloniseTerritoryHandler).Methods("POST")
+	mux.HandleFunc("/maps/{map_id}/alliances", proposeAllianceHandler).Methods("POST")
+	mux.HandleFunc("/maps/{map_id}/alliances/accept", acceptAllianceHandler).Methods("POST")
+	mux.HandleFunc("/maps/{map_id}/alliances/leave", leaveAllianceHandler).Methods("POST")
+	mux.HandleFunc("/maps/{map_id}/wars/join", joinWarHandler).Methods("POST")
+	mux.HandleFunc("/maps/{map_id}/wars/fund", fundWarHandler).Methods("POST")
+	mux.HandleFunc("/maps/{map_id}/recruit", recruitHandler).Methods("POST")
+	mux.HandleFunc("/maps/{map_id}/armies/{army_id}/orders", orderArmyHandler).Methods("POST")
+	mux.HandleFunc("/maps/{map_id}/wars/peace", offerPeaceHandler).Methods("POST")
+	mux.HandleFunc("/maps/{map_id}/wars/peace/accept", acceptPeaceHandler).Methods("POST")
+	mux.HandleFunc("/maps/{map_id}/wars/peace/reject", rejectPeaceHandler).Methods("POST")
 	mux.HandleFunc("/maps", postMapHandler).Methods("POST")
 
 	mux.NotFoundHandler = http.HandlerFunc(NotFoundHandler)
 	mux.MethodNotAllowedHandler = http.HandlerFunc(MethodNotAllowedHandler)
 
+	go runScheduler(time.NewTicker(schedulerInterval).C)
+
 	http.ListenAndServe(":5000", mux)
 }
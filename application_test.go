@@ -1,14 +1,18 @@
 package main
 
 import (
+	"errors"
 	"html/template"
 	"io/ioutil"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/brickman1444/NSImperialism/ai"
 	"github.com/brickman1444/NSImperialism/databasemap"
 	"github.com/brickman1444/NSImperialism/nationstates_api"
-	"github.com/brickman1444/NSImperialism/war"
+	"github.com/brickman1444/NSImperialism/repository"
+	"github.com/brickman1444/NSImperialism/strategicmap"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -48,6 +52,7 @@ func TestACompletedWarChangesResidenceOfTheTerritory(t *testing.T) {
 		nationStatesProvider.PutNationData(*attacker)
 
 		residentNations := databasemap.NewDatabaseMapWithTerritories([]string{"A"})
+		residentNations.Seed = int64(simulationCount)
 
 		theWar := databasemap.NewWar(attacker.Id, defender.Id, "", "A", residentNations.Year)
 
@@ -55,15 +60,16 @@ func TestACompletedWarChangesResidenceOfTheTerritory(t *testing.T) {
 		assert.NoError(t, err)
 
 		residentNations.PutWars([]databasemap.DatabaseWar{theWar})
+		residentNations.Armies["1"] = databasemap.DatabaseArmy{ID: "1", Owner: attacker.Id, TerritoryID: "A", Regiments: 1}
 
 		for warTurnCount := 0; warTurnCount < 1000; warTurnCount++ {
 
-			tick(&residentNations, nationStatesProvider)
+			tick(&residentNations, strategicmap.StaticMap, nationStatesProvider, []ai.Player{}, residentNations.NewRandomForYear())
 
 			wars := residentNations.GetWars()
 			assert.Len(t, wars, 1)
 
-			if !wars[0].IsOngoing {
+			if !wars[0].IsOngoing || residentNations.GetRegiments(attacker.Id) == 0 {
 				break
 			}
 		}
@@ -71,24 +77,15 @@ func TestACompletedWarChangesResidenceOfTheTerritory(t *testing.T) {
 		wars := residentNations.GetWars()
 		assert.Len(t, wars, 1)
 
-		finishedWar := wars[0]
-		assert.False(t, finishedWar.IsOngoing)
-
-		advantageID := war.WarAdvantage(finishedWar)
-		assert.NotNil(t, advantageID)
-
-		if *advantageID == attacker.Id {
-			newResidentID, err := residentNations.GetResident("A")
-
-			assert.NoError(t, err)
-
-			assert.Equal(t, attacker.Id, newResidentID)
-		} else {
-			newResidentID, err := residentNations.GetResident("A")
-
-			assert.NoError(t, err)
+		newResidentID, err := residentNations.GetResident("A")
+		assert.NoError(t, err)
 
+		if wars[0].IsOngoing {
+			assert.Zero(t, residentNations.GetRegiments(attacker.Id))
 			assert.Equal(t, defender.Id, newResidentID)
+		} else {
+			assert.Equal(t, attacker.Id, wars[0].Victor)
+			assert.Equal(t, attacker.Id, newResidentID)
 		}
 	}
 }
@@ -108,8 +105,9 @@ func TestApplicationTickUpdatesWars(t *testing.T) {
 	residentNations.SetResident("A", defender.Id)
 
 	residentNations.PutWars([]databasemap.DatabaseWar{databasemap.NewWar(attacker.Id, defender.Id, "warForA", "A", 0)})
+	residentNations.Armies["1"] = databasemap.DatabaseArmy{ID: "1", Owner: attacker.Id, TerritoryID: "A", Regiments: 1}
 
-	tick(&residentNations, nationStatesProvider)
+	tick(&residentNations, strategicmap.StaticMap, nationStatesProvider, []ai.Player{}, residentNations.NewRandomForYear())
 
 	retrievedWars := residentNations.GetWars()
 
@@ -118,3 +116,545 @@ func TestApplicationTickUpdatesWars(t *testing.T) {
 	assert.Equal(t, "warForA", retrievedWars[0].ID)
 	assert.NotEqual(t, 0, retrievedWars[0].Score)
 }
+
+func TestTickLetsAnAIPlayerOnTheMapDeclareWar(t *testing.T) {
+
+	empire := ai.NewEmpire()
+
+	nationStatesProvider := nationstates_api.NewNationStatesProviderSimpleMap()
+	defender := &nationstates_api.Nation{Id: "Defender", Demonym: "Defending"}
+	defender.SetDefenseForces(50)
+	nationStatesProvider.PutNationData(*defender)
+	nationStatesProvider.PutNationData(empire.GetNation())
+
+	residentNations := databasemap.NewDatabaseMapWithTerritories([]string{"A", "B"})
+	residentNations.SetResident("A", empire.GetNation().Id)
+	residentNations.SetResident("B", defender.Id)
+
+	err := tick(&residentNations, strategicmap.StaticMap, nationStatesProvider, []ai.Player{empire}, residentNations.NewRandomForYear())
+	assert.NoError(t, err)
+
+	wars := residentNations.GetWars()
+	assert.Len(t, wars, 1)
+	assert.Equal(t, empire.GetNation().Id, wars[0].Attacker)
+	assert.Equal(t, defender.Id, wars[0].Defender)
+	assert.Equal(t, "B", wars[0].TerritoryName)
+}
+
+func TestTickIgnoresAnAIPlayerNotOnTheMap(t *testing.T) {
+
+	nationStatesProvider := nationstates_api.NewNationStatesProviderSimpleMap()
+	defender := &nationstates_api.Nation{Id: "Defender"}
+	nationStatesProvider.PutNationData(*defender)
+
+	residentNations := databasemap.NewDatabaseMapWithTerritories([]string{"A"})
+	residentNations.SetResident("A", defender.Id)
+
+	err := tick(&residentNations, strategicmap.StaticMap, nationStatesProvider, []ai.Player{ai.NewEmpire()}, residentNations.NewRandomForYear())
+	assert.NoError(t, err)
+
+	assert.Empty(t, residentNations.GetWars())
+}
+
+type brokenAIPlayer struct {
+}
+
+func (player brokenAIPlayer) GetNation() nationstates_api.Nation {
+	return nationstates_api.Nation{Id: "broken"}
+}
+
+func (player brokenAIPlayer) ChooseWarTarget(databaseMap databasemap.DatabaseMap, warTargets []string, nationStatesProvider nationstates_api.NationStatesProvider) (string, error) {
+	return "", errors.New("The AI broke")
+}
+
+func (player brokenAIPlayer) CommandArmies(databaseMap *databasemap.DatabaseMap, areNeighbours databasemap.AreNeighbours) error {
+	return nil
+}
+
+func TestTickSkipsAnAIPlayerThatFails(t *testing.T) {
+
+	nationStatesProvider := nationstates_api.NewNationStatesProviderSimpleMap()
+
+	residentNations := databasemap.NewDatabaseMapWithTerritories([]string{"A", "B"})
+	residentNations.SetResident("A", "broken")
+	residentNations.SetResident("B", "nation1")
+
+	assert.NoError(t, tick(&residentNations, strategicmap.StaticMap, nationStatesProvider, []ai.Player{brokenAIPlayer{}}, residentNations.NewRandomForYear()))
+	assert.Equal(t, 1, residentNations.Year)
+}
+
+func TestCanAttackABorderingTerritory(t *testing.T) {
+
+	strategicMap := strategicmap.Map{Borders: []strategicmap.Border{{A: "A", B: "B"}}}
+
+	databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A", "B", "C"})
+	databaseMap.SetResident("A", "attacker")
+	databaseMap.SetResident("B", "defender")
+	databaseMap.SetResident("C", "defender")
+
+	canAttackB, _ := canAttack(nationstates_api.Nation{Id: "attacker"}, databaseMap.Cells["B"], databaseMap, strategicMap)
+	assert.True(t, canAttackB)
+
+	canAttackC, reason := canAttack(nationstates_api.Nation{Id: "attacker"}, databaseMap.Cells["C"], databaseMap, strategicMap)
+	assert.False(t, canAttackC)
+	assert.NotEmpty(t, reason)
+}
+
+func TestCantAttackAnAlly(t *testing.T) {
+
+	strategicMap := strategicmap.Map{Borders: []strategicmap.Border{{A: "A", B: "B"}}}
+
+	databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A", "B"})
+	databaseMap.SetResident("A", "attacker")
+	databaseMap.SetResident("B", "ally")
+	assert.NoError(t, databaseMap.ProposeAlliance("attacker", "ally"))
+	assert.NoError(t, databaseMap.AcceptAlliance("ally", "attacker"))
+
+	canAttackB, reason := canAttack(nationstates_api.Nation{Id: "attacker"}, databaseMap.Cells["B"], databaseMap, strategicMap)
+	assert.False(t, canAttackB)
+	assert.NotEmpty(t, reason)
+}
+
+func TestWarTargetsOnlyIncludeBorderingTerritories(t *testing.T) {
+
+	strategicMap := strategicmap.Map{Borders: []strategicmap.Border{{A: "A", B: "B"}, {A: "B", B: "C"}}}
+
+	databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A", "B", "C"})
+	databaseMap.SetResident("A", "attacker")
+	databaseMap.SetResident("B", "defender")
+	databaseMap.SetResident("C", "defender")
+
+	warTargets := getWarTargets(&nationstates_api.Nation{Id: "attacker"}, databaseMap, strategicMap)
+	assert.Len(t, warTargets, 1)
+	assert.Equal(t, "B", warTargets[0].ID)
+}
+
+func TestUpdateMapRetriesAfterAConflictingSave(t *testing.T) {
+
+	globalRepository = repository.NewMemoryRepository()
+
+	databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A", "B"})
+	databaseMap.ID = "map1"
+	assert.NoError(t, globalRepository.PutMap(databaseMap))
+
+	attemptCount := 0
+	err := updateMap("map1", func(databaseMap *databasemap.DatabaseMap) error {
+		attemptCount++
+
+		if attemptCount == 1 {
+			otherPlayersCopy, err := globalRepository.GetMap("map1")
+			assert.NoError(t, err)
+			otherPlayersCopy.SetResident("B", "otherPlayer")
+			assert.NoError(t, globalRepository.PutMap(otherPlayersCopy))
+		}
+
+		return databaseMap.SetResident("A", "player")
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attemptCount)
+
+	savedMap, err := globalRepository.GetMap("map1")
+	assert.NoError(t, err)
+	assert.Equal(t, "player", savedMap.Cells["A"].Resident)
+	assert.Equal(t, "otherPlayer", savedMap.Cells["B"].Resident)
+}
+
+func TestUpdateMapReportsAConflictThatKeepsHappening(t *testing.T) {
+
+	globalRepository = repository.NewMemoryRepository()
+
+	databaseMap := databasemap.NewBlankDatabaseMap()
+	databaseMap.ID = "map1"
+	assert.NoError(t, globalRepository.PutMap(databaseMap))
+
+	err := updateMap("map1", func(databaseMap *databasemap.DatabaseMap) error {
+		otherPlayersCopy, err := globalRepository.GetMap("map1")
+		assert.NoError(t, err)
+		assert.NoError(t, globalRepository.PutMap(otherPlayersCopy))
+		return nil
+	})
+	assert.True(t, databasemap.IsVersionConflict(err))
+}
+
+func TestTickingFromTheSameStateAndSeedIsRepeatable(t *testing.T) {
+
+	nationStatesProvider := nationstates_api.NewNationStatesProviderSimpleMap()
+	for _, nationID := range []string{"nation1", "nation2", "nation3"} {
+		nation := nationstates_api.Nation{Id: nationID}
+		nation.SetDefenseForces(50)
+		nationStatesProvider.PutNationData(nation)
+	}
+
+	makeMap := func() databasemap.DatabaseMap {
+		databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A", "B", "C"})
+		databaseMap.Seed = 42
+		databaseMap.SetResident("A", "nation1")
+		databaseMap.SetResident("B", "nation2")
+		databaseMap.SetResident("C", "nation3")
+		databaseMap.PutWars([]databasemap.DatabaseWar{
+			databasemap.NewWar("nation1", "nation2", "warForB", "B", 0),
+			databasemap.NewWar("nation2", "nation3", "warForC", "C", 0),
+		})
+		databaseMap.Armies["1"] = databasemap.DatabaseArmy{ID: "1", Owner: "nation1", TerritoryID: "B", Regiments: 3}
+		databaseMap.Armies["2"] = databasemap.DatabaseArmy{ID: "2", Owner: "nation2", TerritoryID: "C", Regiments: 3}
+		return databaseMap
+	}
+
+	firstMap := makeMap()
+	secondMap := makeMap()
+
+	for year := 0; year < 5; year++ {
+		assert.NoError(t, tick(&firstMap, strategicmap.StaticMap, nationStatesProvider, []ai.Player{}, firstMap.NewRandomForYear()))
+		assert.NoError(t, tick(&secondMap, strategicmap.StaticMap, nationStatesProvider, []ai.Player{}, secondMap.NewRandomForYear()))
+	}
+
+	assert.Equal(t, firstMap.Cells, secondMap.Cells)
+	assert.Equal(t, firstMap.Wars, secondMap.Wars)
+	assert.Equal(t, firstMap.Armies, secondMap.Armies)
+}
+
+func TestWinningAWarOfLiberationReturnsTheTerritoryToItsFormerResident(t *testing.T) {
+
+	strategicMap := strategicmap.Map{Borders: []strategicmap.Border{{A: "A", B: "B"}}}
+
+	nationStatesProvider := nationstates_api.NewNationStatesProviderSimpleMap()
+	liberator := nationstates_api.Nation{Id: "liberator"}
+	liberator.SetDefenseForces(0)
+	nationStatesProvider.PutNationData(liberator)
+	occupier := nationstates_api.Nation{Id: "occupier"}
+	occupier.SetDefenseForces(100)
+	nationStatesProvider.PutNationData(occupier)
+
+	databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A", "B"})
+	databaseMap.SetResident("A", liberator.Id)
+	databaseMap.SetResident("B", "original")
+	databaseMap.SetResident("B", occupier.Id)
+
+	assert.Error(t, declareWar(&databaseMap, strategicMap, liberator, "B", databasemap.Reconquest, nationStatesProvider))
+	assert.NoError(t, declareWar(&databaseMap, strategicMap, liberator, "B", databasemap.Liberation, nationStatesProvider))
+
+	wars := databaseMap.GetWars()
+	assert.Len(t, wars, 1)
+	assert.Equal(t, databasemap.Liberation, wars[0].Occasion)
+	assert.Equal(t, "original", wars[0].LiberatedFor)
+
+	databaseMap.Armies["1"] = databasemap.DatabaseArmy{ID: "1", Owner: liberator.Id, TerritoryID: "B", Regiments: 100}
+
+	for year := 0; year < 100 && databaseMap.GetWars()[0].IsOngoing; year++ {
+		assert.NoError(t, tick(&databaseMap, strategicMap, nationStatesProvider, []ai.Player{}, databaseMap.NewRandomForYear()))
+	}
+
+	assert.Equal(t, "original", databaseMap.Cells["B"].Resident)
+}
+
+func TestTickPaysEachNationItsIncome(t *testing.T) {
+
+	nationStatesProvider := nationstates_api.NewNationStatesProviderSimpleMap()
+
+	databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A", "B"})
+	databaseMap.SetResident("A", "nation1")
+	databaseMap.SetResident("B", "nation1")
+
+	assert.NoError(t, tick(&databaseMap, strategicmap.StaticMap, nationStatesProvider, []ai.Player{}, databaseMap.NewRandomForYear()))
+
+	assert.Equal(t, databaseMap.GetIncome("nation1"), databaseMap.GetTreasury("nation1"))
+	assert.NotZero(t, databaseMap.GetTreasury("nation1"))
+}
+
+func TestTickMarchesArmiesToTheirDestination(t *testing.T) {
+
+	nationStatesProvider := nationstates_api.NewNationStatesProviderSimpleMap()
+
+	databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A", "B"})
+	databaseMap.SetResident("A", "nation1")
+	databaseMap.SetResident("B", "nation1")
+	databaseMap.Treasuries["nation1"] = databasemap.RecruitCost
+
+	assert.NoError(t, databaseMap.Recruit("nation1", "A"))
+	assert.NoError(t, databaseMap.OrderArmyMove("1", "nation1", "B", strategicmap.StaticMap.AreNeighbours))
+
+	assert.NoError(t, tick(&databaseMap, strategicmap.StaticMap, nationStatesProvider, []ai.Player{}, databaseMap.NewRandomForYear()))
+
+	assert.Equal(t, "B", databaseMap.Armies["1"].TerritoryID)
+}
+
+func TestTheSchedulerTicksOnlyMapsThatAreDue(t *testing.T) {
+
+	globalRepository = repository.NewMemoryRepository()
+	yearStart := time.Date(2021, 3, 14, 9, 26, 0, 0, time.UTC)
+
+	for mapID, tickCadence := range map[string]databasemap.Cadence{"hourly": databasemap.HourlyCadence, "daily": databasemap.DailyCadence, "manual": databasemap.ManualCadence} {
+		databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A"})
+		databaseMap.ID = mapID
+		databaseMap.TickCadence = tickCadence
+		databaseMap.StartOrderPhase(yearStart)
+		assert.NoError(t, globalRepository.PutMap(databaseMap))
+	}
+
+	tickScheduledMaps(yearStart.Add(time.Hour))
+	tickScheduledMaps(yearStart.Add(time.Hour))
+
+	for mapID, expectedYear := range map[string]int{"hourly": 1, "daily": 0, "manual": 0} {
+		databaseMap, err := globalRepository.GetMap(mapID)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedYear, databaseMap.Year, mapID)
+	}
+
+	_, err := globalRepository.GetSnapshot("hourly", 1)
+	assert.NoError(t, err)
+}
+
+func TestAMapSavedByAnOlderVersionOfTheGameCanBeTicked(t *testing.T) {
+
+	globalRepository = repository.NewMemoryRepository()
+
+	nationStatesProvider := nationstates_api.NewNationStatesProviderSimpleMap()
+	for _, nationID := range []string{"nation1", "nation2"} {
+		nation := nationstates_api.Nation{Id: nationID}
+		nation.SetDefenseForces(50)
+		nationStatesProvider.PutNationData(nation)
+	}
+	globalNationStatesProvider = ai.NewNationStatesProviderWithAI(nationStatesProvider, globalAIPlayers)
+	defer func() {
+		globalNationStatesProvider = ai.NewNationStatesProviderWithAI(nationstates_api.NationStatesProviderAPI{}, globalAIPlayers)
+	}()
+
+	// Only what the earliest maps were saved with
+	legacyMap := databasemap.DatabaseMap{
+		ID:   "legacy",
+		Name: "Legacy",
+		Cells: map[string]databasemap.DatabaseCell{
+			"A": {ID: "A", Resident: "nation1"},
+			"B": {ID: "B", Resident: "nation2", FormerResidents: []string{"nation1"}},
+		},
+		Wars: map[string]databasemap.DatabaseWar{"warForB": databasemap.NewWar("nation1", "nation2", "warForB", "B", 0)},
+	}
+	assert.NoError(t, globalRepository.PutMap(legacyMap))
+
+	for _, nationID := range []string{"nation1", "nation2"} {
+		assert.NoError(t, updateMap("legacy", func(databaseMap *databasemap.DatabaseMap) error {
+			err := databaseMap.SubmitOrders(nationID)
+			if err != nil {
+				return err
+			}
+
+			if !databaseMap.IsReadyToResolve(time.Time{}, getAIPlayerIDs(globalAIPlayers)) {
+				return nil
+			}
+
+			return resolveYear(databaseMap, time.Time{})
+		}))
+	}
+
+	databaseMap, err := getMap("legacy")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, databaseMap.Year)
+	assert.Equal(t, []string{"nation1", "nation2"}, databaseMap.Participants)
+	assert.Equal(t, strategicmap.DefaultLayoutID, databaseMap.LayoutID)
+	assert.Equal(t, "nation1", databaseMap.Cells["B"].OriginalResident)
+	assert.NotZero(t, databaseMap.GetTreasury("nation1"))
+
+	_, err = globalRepository.GetSnapshot("legacy", 1)
+	assert.NoError(t, err)
+}
+
+func makeOrdersTestMap() databasemap.DatabaseMap {
+	globalRepository = repository.NewMemoryRepository()
+
+	databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A", "B", "C"})
+	databaseMap.ID = "map1"
+	databaseMap.LayoutID = strategicmap.DefaultLayoutID
+	databaseMap.SetResident("A", "nation1")
+	databaseMap.SetResident("C", "nation2")
+	databaseMap.Treasuries["nation1"] = databasemap.ColonyCost
+	databaseMap.Treasuries["nation2"] = databasemap.ColonyCost
+	return databaseMap
+}
+
+func TestOrdersAreOnlyCarriedOutWhenTheYearResolves(t *testing.T) {
+
+	databaseMap := makeOrdersTestMap()
+
+	assert.NoError(t, giveOrder(&databaseMap, databasemap.DatabaseOrder{Nation: "nation1", Kind: databasemap.RecruitOrder, TerritoryID: "A"}))
+	assert.Empty(t, databaseMap.Armies)
+	assert.Equal(t, databasemap.ColonyCost, databaseMap.GetTreasury("nation1"))
+
+	assert.NoError(t, resolveYear(&databaseMap, time.Time{}))
+	assert.Equal(t, 1, databaseMap.GetRegiments("nation1"))
+	assert.Empty(t, databaseMap.Orders)
+}
+
+func TestAnOrderIsCheckedAgainstTheNationsEarlierOrders(t *testing.T) {
+
+	databaseMap := makeOrdersTestMap()
+	databaseMap.Treasuries["nation1"] = databasemap.RecruitCost
+
+	assert.NoError(t, giveOrder(&databaseMap, databasemap.DatabaseOrder{Nation: "nation1", Kind: databasemap.RecruitOrder, TerritoryID: "A"}))
+	assert.Error(t, giveOrder(&databaseMap, databasemap.DatabaseOrder{Nation: "nation1", Kind: databasemap.RecruitOrder, TerritoryID: "A"}))
+	assert.Error(t, giveOrder(&databaseMap, databasemap.DatabaseOrder{Nation: "nation1", Kind: databasemap.RecruitOrder, TerritoryID: "C"}))
+	assert.Len(t, databaseMap.Orders, 1)
+}
+
+func TestAnOrderIsRefusedWhenTheNationsEarlierOrdersTogetherOverspend(t *testing.T) {
+
+	databaseMap := makeOrdersTestMap()
+	databaseMap.Treasuries["nation1"] = 2 * databasemap.RecruitCost
+
+	assert.NoError(t, giveOrder(&databaseMap, databasemap.DatabaseOrder{Nation: "nation1", Kind: databasemap.RecruitOrder, TerritoryID: "A"}))
+	assert.NoError(t, giveOrder(&databaseMap, databasemap.DatabaseOrder{Nation: "nation1", Kind: databasemap.RecruitOrder, TerritoryID: "A"}))
+
+	databaseMap.Treasuries["nation1"] = databasemap.RecruitCost
+
+	err := giveOrder(&databaseMap, databasemap.DatabaseOrder{Nation: "nation1", Kind: databasemap.FortifyOrder, TerritoryID: "A"})
+	assert.EqualError(t, err, "One of your earlier orders this year is no longer possible: You can't afford that. It costs 10 gold and you have 0.")
+	assert.Len(t, databaseMap.Orders, 2)
+}
+
+func TestEqualClaimsToATerritoryCancelOutAndAreRecordedAsDropped(t *testing.T) {
+
+	databaseMap := makeOrdersTestMap()
+
+	assert.NoError(t, giveOrder(&databaseMap, databasemap.DatabaseOrder{Nation: "nation1", Kind: databasemap.ColoniseOrder, TerritoryID: "B"}))
+	assert.NoError(t, giveOrder(&databaseMap, databasemap.DatabaseOrder{Nation: "nation2", Kind: databasemap.ColoniseOrder, TerritoryID: "B"}))
+
+	assert.NoError(t, resolveYear(&databaseMap, time.Time{}))
+	assert.Empty(t, databaseMap.Cells["B"].Resident)
+	assert.Len(t, databaseMap.DroppedOrders, 2)
+
+	snapshot, err := globalRepository.GetSnapshot("map1", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, databaseMap.DroppedOrders, snapshot.DroppedOrders)
+}
+
+func TestTheStrongestClaimToATerritoryWinsWhicheverWasGivenFirst(t *testing.T) {
+
+	databaseMap := makeOrdersTestMap()
+	databaseMap.Armies["1"] = databasemap.DatabaseArmy{ID: "1", Owner: "nation2", TerritoryID: "C", Regiments: 1}
+
+	assert.NoError(t, giveOrder(&databaseMap, databasemap.DatabaseOrder{Nation: "nation1", Kind: databasemap.ColoniseOrder, TerritoryID: "B"}))
+	assert.NoError(t, giveOrder(&databaseMap, databasemap.DatabaseOrder{Nation: "nation2", Kind: databasemap.ColoniseOrder, TerritoryID: "B"}))
+
+	assert.NoError(t, resolveYear(&databaseMap, time.Time{}))
+	assert.Equal(t, "nation2", databaseMap.Cells["B"].Resident)
+	assert.Len(t, databaseMap.DroppedOrders, 1)
+	assert.Equal(t, "nation1", databaseMap.DroppedOrders[0].Order.Nation)
+}
+
+func TestOrdersThatAreNoLongerPossibleAreDroppedWithTheReason(t *testing.T) {
+
+	databaseMap := makeOrdersTestMap()
+
+	assert.NoError(t, giveOrder(&databaseMap, databasemap.DatabaseOrder{Nation: "nation1", Kind: databasemap.ColoniseOrder, TerritoryID: "B"}))
+	databaseMap.Treasuries["nation1"] = 0
+
+	assert.NoError(t, resolveYear(&databaseMap, time.Time{}))
+	assert.Empty(t, databaseMap.Cells["B"].Resident)
+	assert.Equal(t, []databasemap.DatabaseDroppedOrder{{
+		Order:  databasemap.DatabaseOrder{Nation: "nation1", Kind: databasemap.ColoniseOrder, TerritoryID: "B"},
+		Reason: "You can't afford that. It costs 20 gold and you have 0.",
+	}}, databaseMap.DroppedOrders)
+}
+
+func TestANewOrderCantLeaveAnEarlierOneImpossible(t *testing.T) {
+
+	databaseMap := makeOrdersTestMap()
+
+	assert.NoError(t, giveOrder(&databaseMap, databasemap.DatabaseOrder{Nation: "nation1", Kind: databasemap.RecruitOrder, TerritoryID: "A"}))
+
+	err := giveOrder(&databaseMap, databasemap.DatabaseOrder{Nation: "nation1", Kind: databasemap.ColoniseOrder, TerritoryID: "B"})
+	assert.EqualError(t, err, "That order would leave one of your earlier orders this year impossible: You can't afford that. It costs 10 gold and you have 0.")
+	assert.Len(t, databaseMap.Orders, 1)
+}
+
+func TestAYearsHistoryStartsWithTheBattlesThatOpenedIt(t *testing.T) {
+
+	databaseMap := makeOrdersTestMap()
+
+	nationStatesProvider := nationstates_api.NewNationStatesProviderSimpleMap()
+	nationStatesProvider.PutNationData(nationstates_api.Nation{Id: "nation1"})
+	nationStatesProvider.PutNationData(nationstates_api.Nation{Id: "nation2"})
+	globalNationStatesProvider = ai.NewNationStatesProviderWithAI(nationStatesProvider, globalAIPlayers)
+	defer func() {
+		globalNationStatesProvider = ai.NewNationStatesProviderWithAI(nationstates_api.NationStatesProviderAPI{}, globalAIPlayers)
+	}()
+
+	databaseMap.PutWars([]databasemap.DatabaseWar{databasemap.NewWar("nation1", "nation2", "war1", "C", 0)})
+	databaseMap.Armies["1"] = databasemap.DatabaseArmy{ID: "1", Owner: "nation1", TerritoryID: "C", Regiments: 1}
+
+	assert.NoError(t, resolveYear(&databaseMap, time.Time{}))
+
+	snapshot, err := globalRepository.GetSnapshot("map1", 1)
+	assert.NoError(t, err)
+	assert.Len(t, snapshot.Wars["war1"].Battles, 1)
+	assert.Equal(t, 1, snapshot.Wars["war1"].Battles[0].Year)
+
+	_, err = globalRepository.GetSnapshot("map1", 0)
+	assert.Equal(t, repository.SnapshotDoesntExistError, err)
+}
+
+func TestANationCantGiveOrdersAfterSubmittingThem(t *testing.T) {
+
+	databaseMap := makeOrdersTestMap()
+	assert.NoError(t, databaseMap.SubmitOrders("nation1"))
+
+	assert.Error(t, giveOrder(&databaseMap, databasemap.DatabaseOrder{Nation: "nation1", Kind: databasemap.RecruitOrder, TerritoryID: "A"}))
+	assert.NoError(t, giveOrder(&databaseMap, databasemap.DatabaseOrder{Nation: "nation2", Kind: databasemap.RecruitOrder, TerritoryID: "C"}))
+}
+
+func TestTickFinishesTheMapOnceAVictoryConditionIsMet(t *testing.T) {
+
+	nationStatesProvider := nationstates_api.NewNationStatesProviderSimpleMap()
+
+	databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A", "B"})
+	databaseMap.SetResident("A", "nation1")
+	databaseMap.SetResident("B", "nation1")
+	databaseMap.VictoryConditions = []databasemap.VictoryCondition{databasemap.Domination}
+
+	assert.NoError(t, tick(&databaseMap, strategicmap.StaticMap, nationStatesProvider, []ai.Player{}, databaseMap.NewRandomForYear()))
+	assert.True(t, databaseMap.IsFinished)
+	assert.Equal(t, 1, databaseMap.FinishedYear)
+
+	assert.Error(t, tick(&databaseMap, strategicmap.StaticMap, nationStatesProvider, []ai.Player{}, databaseMap.NewRandomForYear()))
+	assert.Equal(t, 1, databaseMap.Year)
+
+	globalRepository = repository.NewMemoryRepository()
+	databaseMap.LayoutID = strategicmap.DefaultLayoutID
+	assert.Equal(t, databasemap.MapFinishedError, resolveYear(&databaseMap, time.Time{}))
+
+	canAttackB, _ := canAttack(nationstates_api.Nation{Id: "nation2"}, databaseMap.Cells["B"], databaseMap, strategicmap.StaticMap)
+	assert.False(t, canAttackB)
+}
+
+func TestMapsArePlayedOnTheLayoutTheyWereCreatedWith(t *testing.T) {
+
+	smallLayout := strategicmap.Map{ID: "small", Territories: []strategicmap.Territory{{ID: "A"}, {ID: "B"}}, Borders: []strategicmap.Border{{A: "A", B: "B"}}}
+	globalLayouts = []strategicmap.Map{strategicmap.StaticMap, smallLayout}
+	defer func() { globalLayouts = []strategicmap.Map{strategicmap.StaticMap} }()
+
+	layout, err := getLayout(databasemap.DatabaseMap{LayoutID: strategicmap.DefaultLayoutID})
+	assert.NoError(t, err)
+	assert.Equal(t, strategicmap.DefaultLayoutID, layout.ID)
+
+	layout, err = getLayout(databasemap.DatabaseMap{LayoutID: "small"})
+	assert.NoError(t, err)
+	assert.True(t, layout.AreNeighbours("A", "B"))
+
+	_, err = getLayout(databasemap.DatabaseMap{LayoutID: "removed"})
+	assert.Error(t, err)
+}
+
+func TestMapsOnAGeneratedLayoutKeepItWithThem(t *testing.T) {
+
+	generatedLayout, err := strategicmap.GenerateLayout(strategicmap.DefaultGeneratedTerritories, 99)
+	assert.NoError(t, err)
+
+	databaseMap, err := strategicmap.MakeNewRandomMap(generatedLayout, []string{"nation1", "nation2"}, "name", 99)
+	assert.NoError(t, err)
+	assert.NotNil(t, databaseMap.GeneratedLayout)
+
+	layout, err := getLayout(databaseMap)
+	assert.NoError(t, err)
+	assert.Equal(t, generatedLayout.Borders, layout.Borders)
+	assert.Equal(t, generatedLayout.Outlines, layout.Outlines)
+	assert.Equal(t, "/maps/"+databaseMap.ID+"/background.svg", layout.BackgroundImage)
+}
@@ -0,0 +1,166 @@
+package databasemap
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+type DatabaseAlliance struct {
+	ID         string
+	Proposer   string
+	Invitee    string
+	IsAccepted bool
+	StartYear  int
+}
+
+// AllianceID is the same whichever nation proposed so there is only ever one alliance between two nations
+func AllianceID(nationA string, nationB string) string {
+	nationIDs := []string{nationA, nationB}
+	sort.Strings(nationIDs)
+	return strings.Join(nationIDs, "+")
+}
+
+func (alliance DatabaseAlliance) Includes(nationID string) bool {
+	return alliance.Proposer == nationID || alliance.Invitee == nationID
+}
+
+func (alliance DatabaseAlliance) Other(nationID string) string {
+	if alliance.Proposer == nationID {
+		return alliance.Invitee
+	}
+	return alliance.Proposer
+}
+
+func (databaseMap DatabaseMap) AreAllied(nationA string, nationB string) bool {
+	alliance, doesAllianceExist := databaseMap.Alliances[AllianceID(nationA, nationB)]
+	return doesAllianceExist && alliance.IsAccepted
+}
+
+func (databaseMap DatabaseMap) GetAlliances() []DatabaseAlliance {
+	alliances := make([]DatabaseAlliance, 0, len(databaseMap.Alliances))
+	for _, alliance := range databaseMap.Alliances {
+		alliances = append(alliances, alliance)
+	}
+
+	sort.Slice(alliances, func(i, j int) bool {
+		return alliances[i].ID < alliances[j].ID
+	})
+
+	return alliances
+}
+
+// ProposeAlliance accepts the alliance instead if the invitee had already proposed it
+func (databaseMap *DatabaseMap) ProposeAlliance(proposer string, invitee string) error {
+	err := databaseMap.CheckNotFinished()
+	if err != nil {
+		return err
+	}
+
+	if proposer == invitee {
+		return errors.New("You can't ally with yourself")
+	}
+
+	if !databaseMap.HasParticipant(invitee) {
+		return errors.New("That nation isn't participating in this map")
+	}
+
+	allianceID := AllianceID(proposer, invitee)
+	alliance, doesAllianceExist := databaseMap.Alliances[allianceID]
+	if doesAllianceExist {
+		if alliance.IsAccepted {
+			return errors.New("You're already allied with that nation")
+		}
+
+		if alliance.Proposer == proposer {
+			return errors.New("You've already proposed an alliance with that nation")
+		}
+
+		return databaseMap.AcceptAlliance(proposer, invitee)
+	}
+
+	databaseMap.Alliances[allianceID] = DatabaseAlliance{
+		ID:       allianceID,
+		Proposer: proposer,
+		Invitee:  invitee,
+	}
+
+	return nil
+}
+
+func (databaseMap *DatabaseMap) AcceptAlliance(invitee string, proposer string) error {
+	err := databaseMap.CheckNotFinished()
+	if err != nil {
+		return err
+	}
+
+	allianceID := AllianceID(proposer, invitee)
+	alliance, doesAllianceExist := databaseMap.Alliances[allianceID]
+	if !doesAllianceExist || alliance.Invitee != invitee {
+		return errors.New("That nation hasn't proposed an alliance with you")
+	}
+
+	if alliance.IsAccepted {
+		return errors.New("You're already allied with that nation")
+	}
+
+	alliance.IsAccepted = true
+	alliance.StartYear = databaseMap.Year
+	databaseMap.Alliances[allianceID] = alliance
+
+	return nil
+}
+
+// BreakAlliance also withdraws or declines a proposed alliance
+func (databaseMap *DatabaseMap) BreakAlliance(nationID string, otherNationID string) error {
+	err := databaseMap.CheckNotFinished()
+	if err != nil {
+		return err
+	}
+
+	allianceID := AllianceID(nationID, otherNationID)
+	_, doesAllianceExist := databaseMap.Alliances[allianceID]
+	if !doesAllianceExist {
+		return errors.New("You don't have an alliance with that nation")
+	}
+
+	delete(databaseMap.Alliances, allianceID)
+
+	return nil
+}
+
+// JoinWar adds the nation to the given side of an ongoing war, which requires an alliance with that side's leader
+func (databaseMap *DatabaseMap) JoinWar(warID string, nationID string, side string) error {
+	err := databaseMap.CheckNotFinished()
+	if err != nil {
+		return err
+	}
+
+	war, doesWarExist := databaseMap.Wars[warID]
+	if !doesWarExist || !war.IsOngoing {
+		return errors.New("That war isn't being fought")
+	}
+
+	if war.IsParticipant(nationID) {
+		return errors.New("You're already fighting in that war")
+	}
+
+	switch side {
+	case AttackingSide:
+		if !databaseMap.AreAllied(nationID, war.Attacker) {
+			return errors.New("You can only join a war on the side of an ally")
+		}
+		war.AttackerAllies = append(war.AttackerAllies, nationID)
+	case DefendingSide:
+		if !databaseMap.AreAllied(nationID, war.Defender) {
+			return errors.New("You can only join a war on the side of an ally")
+		}
+		war.DefenderAllies = append(war.DefenderAllies, nationID)
+	default:
+		return errors.New("Unknown side of the war")
+	}
+
+	databaseMap.Wars[warID] = war
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package databasemap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func makeAllianceTestMap() DatabaseMap {
+	databaseMap := NewDatabaseMapWithTerritories([]string{"A", "B", "C"})
+	databaseMap.SetResident("A", "nation1")
+	databaseMap.SetResident("B", "nation2")
+	databaseMap.SetResident("C", "nation3")
+	return databaseMap
+}
+
+func TestAnAllianceNeedsToBeAccepted(t *testing.T) {
+
+	databaseMap := makeAllianceTestMap()
+
+	assert.NoError(t, databaseMap.ProposeAlliance("nation1", "nation2"))
+	assert.False(t, databaseMap.AreAllied("nation1", "nation2"))
+
+	assert.Error(t, databaseMap.AcceptAlliance("nation1", "nation2"))
+
+	assert.NoError(t, databaseMap.AcceptAlliance("nation2", "nation1"))
+	assert.True(t, databaseMap.AreAllied("nation1", "nation2"))
+	assert.True(t, databaseMap.AreAllied("nation2", "nation1"))
+	assert.False(t, databaseMap.AreAllied("nation1", "nation3"))
+}
+
+func TestProposingAnAllianceThatWasAlreadyProposedToYouAcceptsIt(t *testing.T) {
+
+	databaseMap := makeAllianceTestMap()
+
+	assert.NoError(t, databaseMap.ProposeAlliance("nation1", "nation2"))
+	assert.NoError(t, databaseMap.ProposeAlliance("nation2", "nation1"))
+
+	assert.True(t, databaseMap.AreAllied("nation1", "nation2"))
+	assert.Len(t, databaseMap.GetAlliances(), 1)
+}
+
+func TestCantAllyWithYourselfOrANationNotOnTheMap(t *testing.T) {
+
+	databaseMap := makeAllianceTestMap()
+
+	assert.Error(t, databaseMap.ProposeAlliance("nation1", "nation1"))
+	assert.Error(t, databaseMap.ProposeAlliance("nation1", "nation4"))
+	assert.Empty(t, databaseMap.GetAlliances())
+}
+
+func TestEitherNationCanBreakAnAlliance(t *testing.T) {
+
+	databaseMap := makeAllianceTestMap()
+	databaseMap.ProposeAlliance("nation1", "nation2")
+	databaseMap.AcceptAlliance("nation2", "nation1")
+
+	assert.NoError(t, databaseMap.BreakAlliance("nation2", "nation1"))
+	assert.False(t, databaseMap.AreAllied("nation1", "nation2"))
+	assert.Error(t, databaseMap.BreakAlliance("nation1", "nation2"))
+}
+
+func TestAMapSavedWithoutAlliancesCanStillMakeThem(t *testing.T) {
+
+	databaseMap := makeAllianceTestMap()
+	databaseMap.Alliances = nil
+	databaseMap.Normalize()
+
+	assert.False(t, databaseMap.AreAllied("nation1", "nation2"))
+	assert.NoError(t, databaseMap.ProposeAlliance("nation1", "nation2"))
+}
+
+func TestAnAllyCanJoinAWarOnTheirAllysSide(t *testing.T) {
+
+	databaseMap := makeAllianceTestMap()
+	databaseMap.PutWars([]DatabaseWar{NewWar("nation1", "nation2", "warForB", "B", 0)})
+	databaseMap.ProposeAlliance("nation3", "nation2")
+
+	assert.Error(t, databaseMap.JoinWar("warForB", "nation3", DefendingSide))
+
+	databaseMap.AcceptAlliance("nation2", "nation3")
+
+	assert.Error(t, databaseMap.JoinWar("warForB", "nation3", AttackingSide))
+	assert.NoError(t, databaseMap.JoinWar("warForB", "nation3", DefendingSide))
+	assert.Error(t, databaseMap.JoinWar("warForB", "nation3", DefendingSide))
+
+	theWar := databaseMap.Wars["warForB"]
+	assert.Equal(t, []string{"nation2", "nation3"}, theWar.GetDefenders())
+	assert.Equal(t, []string{"nation1"}, theWar.GetAttackers())
+	assert.True(t, theWar.IsParticipant("nation3"))
+}
+
+func TestCantJoinAFinishedWar(t *testing.T) {
+
+	databaseMap := makeAllianceTestMap()
+	finishedWar := NewWar("nation1", "nation2", "warForB", "B", 0)
+	finishedWar.IsOngoing = false
+	databaseMap.PutWars([]DatabaseWar{finishedWar})
+	databaseMap.ProposeAlliance("nation3", "nation2")
+	databaseMap.AcceptAlliance("nation2", "nation3")
+
+	assert.Error(t, databaseMap.JoinWar("warForB", "nation3", DefendingSide))
+	assert.Error(t, databaseMap.JoinWar("missingWar", "nation3", DefendingSide))
+}
@@ -0,0 +1,210 @@
+package databasemap
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+)
+
+type DatabaseArmy struct {
+	ID          string
+	Owner       string
+	TerritoryID string
+	Regiments   int
+	Destination string // Where the army marches next year. Empty when it has no orders.
+}
+
+// AreNeighbours reports whether two territories share a border on the map's layout
+type AreNeighbours func(territoryA string, territoryB string) bool
+
+func (databaseMap DatabaseMap) GetArmies() []DatabaseArmy {
+	armies := make([]DatabaseArmy, 0, len(databaseMap.Armies))
+	for _, army := range databaseMap.Armies {
+		armies = append(armies, army)
+	}
+
+	sort.Slice(armies, func(i, j int) bool {
+		return compareArmyIDs(armies[i].ID, armies[j].ID)
+	})
+
+	return armies
+}
+
+// Army IDs are numbers so they're ordered numerically and army 10 comes after army 9
+func compareArmyIDs(armyIDA string, armyIDB string) bool {
+	armyNumberA, _ := strconv.Atoi(armyIDA)
+	armyNumberB, _ := strconv.Atoi(armyIDB)
+	return armyNumberA < armyNumberB
+}
+
+func (databaseMap DatabaseMap) GetArmiesOf(nationID string) []DatabaseArmy {
+	armies := []DatabaseArmy{}
+	for _, army := range databaseMap.GetArmies() {
+		if army.Owner == nationID {
+			armies = append(armies, army)
+		}
+	}
+	return armies
+}
+
+func (databaseMap DatabaseMap) GetArmiesAt(territoryID string) []DatabaseArmy {
+	armies := []DatabaseArmy{}
+	for _, army := range databaseMap.GetArmies() {
+		if army.TerritoryID == territoryID {
+			armies = append(armies, army)
+		}
+	}
+	return armies
+}
+
+func (databaseMap DatabaseMap) GetRegiments(nationID string) int {
+	regiments := 0
+	for _, army := range databaseMap.Armies {
+		if army.Owner == nationID {
+			regiments += army.Regiments
+		}
+	}
+	return regiments
+}
+
+// AreAtWar is true when the nations are on opposite sides of an ongoing war
+func (databaseMap DatabaseMap) AreAtWar(nationA string, nationB string) bool {
+	for _, war := range databaseMap.Wars {
+		if !war.IsOngoing {
+			continue
+		}
+
+		attackers := war.GetAttackers()
+		defenders := war.GetDefenders()
+		if (containsNation(attackers, nationA) && containsNation(defenders, nationB)) || (containsNation(attackers, nationB) && containsNation(defenders, nationA)) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsNation(nationIDs []string, nationID string) bool {
+	for _, otherNationID := range nationIDs {
+		if otherNationID == nationID {
+			return true
+		}
+	}
+	return false
+}
+
+// addRegiment reinforces the nation's army in the territory or raises a new one if it has none there
+func (databaseMap *DatabaseMap) addRegiment(nationID string, territoryID string) {
+	for _, army := range databaseMap.GetArmiesAt(territoryID) {
+		if army.Owner == nationID {
+			army.Regiments++
+			databaseMap.Armies[army.ID] = army
+			return
+		}
+	}
+
+	databaseMap.NextArmyNumber++
+	armyID := strconv.Itoa(databaseMap.NextArmyNumber)
+	databaseMap.Armies[armyID] = DatabaseArmy{
+		ID:          armyID,
+		Owner:       nationID,
+		TerritoryID: territoryID,
+		Regiments:   1,
+	}
+}
+
+// removeRegiments takes regiments from the nation's armies, most recently raised first, and disbands any army left empty
+func (databaseMap *DatabaseMap) removeRegiments(nationID string, regimentsToRemove int) {
+	armies := databaseMap.GetArmiesOf(nationID)
+	for armyIndex := len(armies) - 1; armyIndex >= 0 && regimentsToRemove > 0; armyIndex-- {
+		army := armies[armyIndex]
+
+		removedRegiments := regimentsToRemove
+		if removedRegiments > army.Regiments {
+			removedRegiments = army.Regiments
+		}
+
+		army.Regiments -= removedRegiments
+		regimentsToRemove -= removedRegiments
+
+		if army.Regiments == 0 {
+			delete(databaseMap.Armies, army.ID)
+		} else {
+			databaseMap.Armies[army.ID] = army
+		}
+	}
+}
+
+// canEnter lists where an army may march: its owner's or an ally's territory, or the territory of a nation its owner is at war with
+func (databaseMap DatabaseMap) canEnter(nationID string, territoryID string) bool {
+	territory, doesTerritoryExist := databaseMap.Cells[territoryID]
+	if !doesTerritoryExist {
+		return false
+	}
+
+	return territory.Resident == nationID || databaseMap.AreAllied(nationID, territory.Resident) || databaseMap.AreAtWar(nationID, territory.Resident)
+}
+
+// OrderArmyMove marches the army to a bordering territory when the year ends or cancels its orders if the destination is where it is
+func (databaseMap *DatabaseMap) OrderArmyMove(armyID string, nationID string, destination string, areNeighbours AreNeighbours) error {
+	err := databaseMap.CheckNotFinished()
+	if err != nil {
+		return err
+	}
+
+	army, doesArmyExist := databaseMap.Armies[armyID]
+	if !doesArmyExist || army.Owner != nationID {
+		return errors.New("You don't have that army")
+	}
+
+	if destination == army.TerritoryID || len(destination) == 0 {
+		army.Destination = ""
+		databaseMap.Armies[armyID] = army
+		return nil
+	}
+
+	if !areNeighbours(army.TerritoryID, destination) {
+		return errors.New("Armies can only march to a bordering territory")
+	}
+
+	if !databaseMap.canEnter(nationID, destination) {
+		return errors.New("Armies can only march into your own or an ally's territory or the territory of a nation you're at war with")
+	}
+
+	army.Destination = destination
+	databaseMap.Armies[armyID] = army
+
+	return nil
+}
+
+// MoveArmies carries out every army's orders that are still possible and merges armies of the same nation that end up together
+func (databaseMap *DatabaseMap) MoveArmies(areNeighbours AreNeighbours) {
+	for _, army := range databaseMap.GetArmies() {
+		if len(army.Destination) != 0 && areNeighbours(army.TerritoryID, army.Destination) && databaseMap.canEnter(army.Owner, army.Destination) {
+			army.TerritoryID = army.Destination
+		}
+
+		army.Destination = ""
+		databaseMap.Armies[army.ID] = army
+	}
+
+	for _, army := range databaseMap.GetArmies() {
+		for _, otherArmy := range databaseMap.GetArmiesAt(army.TerritoryID) {
+			if otherArmy.ID != army.ID && otherArmy.Owner == army.Owner && compareArmyIDs(army.ID, otherArmy.ID) {
+				army.Regiments += otherArmy.Regiments
+				databaseMap.Armies[army.ID] = army
+				delete(databaseMap.Armies, otherArmy.ID)
+			}
+		}
+	}
+}
+
+// RegimentsAt counts the regiments the given nations have stationed in a territory
+func (databaseMap DatabaseMap) RegimentsAt(territoryID string, nationIDs []string) int {
+	regiments := 0
+	for _, army := range databaseMap.Armies {
+		if army.TerritoryID == territoryID && containsNation(nationIDs, army.Owner) {
+			regiments += army.Regiments
+		}
+	}
+	return regiments
+}
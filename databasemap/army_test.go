@@ -0,0 +1,117 @@
+package databasemap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The test map is a line of territories: A borders B, B borders C and C borders D
+func areNeighboursInALine(territoryA string, territoryB string) bool {
+	return (territoryA[0] == territoryB[0]+1) || (territoryB[0] == territoryA[0]+1)
+}
+
+func makeArmyTestMap() DatabaseMap {
+	databaseMap := NewDatabaseMapWithTerritories([]string{"A", "B", "C", "D"})
+	databaseMap.SetResident("A", "nation1")
+	databaseMap.SetResident("B", "nation1")
+	databaseMap.SetResident("C", "nation2")
+	databaseMap.SetResident("D", "nation3")
+	return databaseMap
+}
+
+func TestRecruitingReinforcesTheArmyAlreadyInTheTerritory(t *testing.T) {
+
+	databaseMap := makeArmyTestMap()
+	databaseMap.Treasuries["nation1"] = 3 * RecruitCost
+
+	assert.NoError(t, databaseMap.Recruit("nation1", "A"))
+	assert.NoError(t, databaseMap.Recruit("nation1", "A"))
+	assert.NoError(t, databaseMap.Recruit("nation1", "B"))
+
+	armies := databaseMap.GetArmiesOf("nation1")
+	assert.Len(t, armies, 2)
+	assert.Equal(t, DatabaseArmy{ID: "1", Owner: "nation1", TerritoryID: "A", Regiments: 2}, armies[0])
+	assert.Equal(t, DatabaseArmy{ID: "2", Owner: "nation1", TerritoryID: "B", Regiments: 1}, armies[1])
+}
+
+func TestArmiesCanOnlyBeOrderedToABorderingTerritoryTheyMayEnter(t *testing.T) {
+
+	databaseMap := makeArmyTestMap()
+	databaseMap.addRegiment("nation1", "B")
+
+	assert.Error(t, databaseMap.OrderArmyMove("1", "nation2", "A", areNeighboursInALine))
+	assert.Error(t, databaseMap.OrderArmyMove("1", "nation1", "D", areNeighboursInALine))
+	assert.Error(t, databaseMap.OrderArmyMove("1", "nation1", "C", areNeighboursInALine))
+
+	databaseMap.PutWars([]DatabaseWar{NewWar("nation1", "nation2", "warForC", "C", 0)})
+	assert.NoError(t, databaseMap.OrderArmyMove("1", "nation1", "C", areNeighboursInALine))
+	assert.Equal(t, "C", databaseMap.Armies["1"].Destination)
+
+	assert.NoError(t, databaseMap.OrderArmyMove("1", "nation1", "", areNeighboursInALine))
+	assert.Empty(t, databaseMap.Armies["1"].Destination)
+}
+
+func TestArmiesMayMarchThroughAnAllysTerritory(t *testing.T) {
+
+	databaseMap := makeArmyTestMap()
+	databaseMap.addRegiment("nation1", "B")
+
+	assert.NoError(t, databaseMap.ProposeAlliance("nation1", "nation2"))
+	assert.NoError(t, databaseMap.AcceptAlliance("nation2", "nation1"))
+
+	assert.NoError(t, databaseMap.OrderArmyMove("1", "nation1", "C", areNeighboursInALine))
+}
+
+func TestMovingArmiesCarriesOutOrdersAndMergesArmies(t *testing.T) {
+
+	databaseMap := makeArmyTestMap()
+	databaseMap.addRegiment("nation1", "A")
+	databaseMap.addRegiment("nation1", "B")
+	databaseMap.addRegiment("nation1", "B")
+
+	assert.NoError(t, databaseMap.OrderArmyMove("1", "nation1", "B", areNeighboursInALine))
+	databaseMap.MoveArmies(areNeighboursInALine)
+
+	assert.Equal(t, []DatabaseArmy{{ID: "1", Owner: "nation1", TerritoryID: "B", Regiments: 3}}, databaseMap.GetArmies())
+}
+
+func TestOrdersIntoAFormerEnemysTerritoryAreDroppedAfterPeace(t *testing.T) {
+
+	databaseMap := makeArmyTestMap()
+	databaseMap.addRegiment("nation1", "B")
+	databaseMap.PutWars([]DatabaseWar{NewWar("nation1", "nation2", "warForC", "C", 0)})
+	assert.NoError(t, databaseMap.OrderArmyMove("1", "nation1", "C", areNeighboursInALine))
+
+	finishedWar := databaseMap.Wars["warForC"]
+	finishedWar.IsOngoing = false
+	databaseMap.PutWars([]DatabaseWar{finishedWar})
+	databaseMap.MoveArmies(areNeighboursInALine)
+
+	assert.Equal(t, "B", databaseMap.Armies["1"].TerritoryID)
+	assert.Empty(t, databaseMap.Armies["1"].Destination)
+}
+
+func TestAlliesOfTheWarLeadersAreAtWarWithTheOtherSide(t *testing.T) {
+
+	databaseMap := makeArmyTestMap()
+	theWar := NewWar("nation1", "nation2", "warForC", "C", 0)
+	theWar.DefenderAllies = []string{"nation3"}
+	databaseMap.PutWars([]DatabaseWar{theWar})
+
+	assert.True(t, databaseMap.AreAtWar("nation1", "nation2"))
+	assert.True(t, databaseMap.AreAtWar("nation3", "nation1"))
+	assert.False(t, databaseMap.AreAtWar("nation2", "nation3"))
+}
+
+func TestDisbandingRegimentsTakesFromTheNewestArmiesFirst(t *testing.T) {
+
+	databaseMap := makeArmyTestMap()
+	databaseMap.addRegiment("nation1", "A")
+	databaseMap.addRegiment("nation1", "A")
+	databaseMap.addRegiment("nation1", "B")
+
+	databaseMap.removeRegiments("nation1", 2)
+
+	assert.Equal(t, []DatabaseArmy{{ID: "1", Owner: "nation1", TerritoryID: "A", Regiments: 1}}, databaseMap.GetArmies())
+}
@@ -0,0 +1,93 @@
+package databasemap
+
+import (
+	"errors"
+	"fmt"
+)
+
+const ColonyCost = 20
+const MinimumNeutralStrength = 10
+const MaximumNeutralStrength = 50
+const NeutralStrengthPerRegiment = 10
+
+// IsNeutral is true for a territory without a resident that defends itself
+func (cell DatabaseCell) IsNeutral() bool {
+	return len(cell.Resident) == 0 && cell.NeutralStrength > 0
+}
+
+// RegimentsToAnnex is how many regiments a nation needs in the territories around a neutral territory to overcome its defenders.
+// Annexing isn't a battle because orders are checked when they're given, so an accepted annexation can't hinge on a roll of the dice,
+// and because neutral defenders have no nation with census scores for the combat model to weigh.
+func (cell DatabaseCell) RegimentsToAnnex() int {
+	if !cell.IsNeutral() {
+		return 0
+	}
+	return (cell.NeutralStrength + NeutralStrengthPerRegiment - 1) / NeutralStrengthPerRegiment
+}
+
+func (databaseMap DatabaseMap) bordersTerritoryOf(nationID string, territoryID string, areNeighbours AreNeighbours) bool {
+	for _, cell := range databaseMap.Cells {
+		if cell.Resident == nationID && areNeighbours(cell.ID, territoryID) {
+			return true
+		}
+	}
+	return false
+}
+
+// RegimentsAround counts the nation's regiments in every territory bordering the given one
+func (databaseMap DatabaseMap) RegimentsAround(nationID string, territoryID string, areNeighbours AreNeighbours) int {
+	regiments := 0
+	for _, army := range databaseMap.Armies {
+		if army.Owner == nationID && areNeighbours(army.TerritoryID, territoryID) {
+			regiments += army.Regiments
+		}
+	}
+	return regiments
+}
+
+// CheckColonise explains why the nation can't colonise the territory or returns nil if it can
+func (databaseMap DatabaseMap) CheckColonise(territoryID string, nationID string, areNeighbours AreNeighbours) error {
+	err := databaseMap.CheckNotFinished()
+	if err != nil {
+		return err
+	}
+
+	territory, doesTerritoryExist := databaseMap.Cells[territoryID]
+	if !doesTerritoryExist {
+		return errors.New("That territory doesn't exist")
+	}
+
+	if len(territory.Resident) != 0 {
+		return errors.New("Only unclaimed and neutral territories can be colonised")
+	}
+
+	if !databaseMap.bordersTerritoryOf(nationID, territoryID, areNeighbours) {
+		return errors.New("You can only colonise a territory bordering one of your own")
+	}
+
+	regimentsAround := databaseMap.RegimentsAround(nationID, territoryID, areNeighbours)
+	if regimentsAround < territory.RegimentsToAnnex() {
+		return fmt.Errorf("Annexing %s takes %d regiments in the territories around it and you have %d", territoryID, territory.RegimentsToAnnex(), regimentsAround)
+	}
+
+	return nil
+}
+
+// Colonise settles an unclaimed territory or annexes a neutral one that borders one of the nation's territories
+func (databaseMap *DatabaseMap) Colonise(territoryID string, nationID string, areNeighbours AreNeighbours) error {
+	err := databaseMap.CheckColonise(territoryID, nationID, areNeighbours)
+	if err != nil {
+		return err
+	}
+
+	err = databaseMap.spend(nationID, ColonyCost)
+	if err != nil {
+		return err
+	}
+
+	territory := databaseMap.Cells[territoryID]
+	territory.NeutralStrength = 0
+	databaseMap.Cells[territoryID] = territory
+
+	return databaseMap.SetResident(territoryID, nationID)
+}
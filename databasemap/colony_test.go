@@ -0,0 +1,58 @@
+package databasemap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func makeColonyTestMap() DatabaseMap {
+	databaseMap := makeArmyTestMap()
+	databaseMap.Cells["C"] = DatabaseCell{ID: "C"}
+	databaseMap.Cells["D"] = DatabaseCell{ID: "D", NeutralStrength: 25}
+	databaseMap.Treasuries["nation1"] = 2 * ColonyCost
+	return databaseMap
+}
+
+func TestAnUnclaimedTerritoryBorderingYoursCanBeColonised(t *testing.T) {
+
+	databaseMap := makeColonyTestMap()
+
+	assert.NoError(t, databaseMap.Colonise("C", "nation1", areNeighboursInALine))
+
+	territory := databaseMap.Cells["C"]
+	assert.Equal(t, "nation1", territory.Resident)
+	assert.Equal(t, "nation1", territory.OriginalResident)
+	assert.False(t, territory.IsOccupied())
+	assert.Equal(t, ColonyCost, databaseMap.GetTreasury("nation1"))
+}
+
+func TestOnlyBorderingTerritoriesWithoutAResidentCanBeColonised(t *testing.T) {
+
+	databaseMap := makeColonyTestMap()
+
+	assert.Error(t, databaseMap.Colonise("B", "nation1", areNeighboursInALine))
+	assert.Error(t, databaseMap.Colonise("C", "nation3", areNeighboursInALine))
+	assert.Error(t, databaseMap.Colonise("missing", "nation1", areNeighboursInALine))
+
+	databaseMap.Treasuries["nation1"] = ColonyCost - 1
+	assert.Error(t, databaseMap.Colonise("C", "nation1", areNeighboursInALine))
+	assert.Empty(t, databaseMap.Cells["C"].Resident)
+}
+
+func TestAnnexingANeutralTerritoryTakesRegimentsAroundIt(t *testing.T) {
+
+	databaseMap := makeColonyTestMap()
+	assert.NoError(t, databaseMap.Colonise("C", "nation1", areNeighboursInALine))
+
+	assert.Equal(t, 3, databaseMap.Cells["D"].RegimentsToAnnex())
+	databaseMap.addRegiment("nation1", "C")
+	databaseMap.addRegiment("nation1", "C")
+	databaseMap.addRegiment("nation1", "B")
+	assert.Error(t, databaseMap.Colonise("D", "nation1", areNeighboursInALine))
+
+	databaseMap.addRegiment("nation1", "C")
+	assert.NoError(t, databaseMap.Colonise("D", "nation1", areNeighboursInALine))
+	assert.Equal(t, "nation1", databaseMap.Cells["D"].Resident)
+	assert.False(t, databaseMap.Cells["D"].IsNeutral())
+}
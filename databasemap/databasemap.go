@@ -1,40 +1,225 @@
 package databasemap
 
-import "errors"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+)
 
 type DatabaseCell struct {
-	ID       string
-	Name     string
-	Resident string
+	ID                 string
+	Name               string
+	Resident           string
+	FormerResidents    []string // Every nation that held the territory before its current resident, oldest first
+	Terrain            Terrain
+	FortificationLevel int
+	NextFortifyYear    int // The first year the fortifications can be raised again
+	Income             int
+	OriginalResident   string // The first nation to hold the territory
+	HeldSinceYear      int    // When the current resident took the territory
+	NeutralStrength    int    // How strongly a territory without a resident resists annexation. Zero for unclaimed territories.
+}
+
+// IsOccupied is true when the territory is held by someone other than the nation it originally belonged to
+func (cell DatabaseCell) IsOccupied() bool {
+	return len(cell.Resident) != 0 && cell.Resident != cell.OriginalResident
+}
+
+func (cell DatabaseCell) WasHeldBy(nationID string) bool {
+	for _, formerResident := range cell.FormerResidents {
+		if formerResident == nationID {
+			return true
+		}
+	}
+	return false
+}
+
+// FormerResident is the nation that held the territory before its current resident or empty if it has never changed hands
+func (cell DatabaseCell) FormerResident() string {
+	for formerResidentIndex := len(cell.FormerResidents) - 1; formerResidentIndex >= 0; formerResidentIndex-- {
+		if cell.FormerResidents[formerResidentIndex] != cell.Resident {
+			return cell.FormerResidents[formerResidentIndex]
+		}
+	}
+	return ""
+}
+
+func (cell DatabaseCell) DisplayName() string {
+	if len(cell.Name) != 0 {
+		return cell.Name
+	}
+	return cell.ID
+}
+
+type DatabaseBattle struct {
+	Year           int
+	TerritoryID    string
+	AttackerForces int
+	DefenderForces int
+	Roll           int // Drawn from zero up to the sum of both forces. Rolls below the forces holding the territory are won by them.
+	Winner         string
+	ScoreDelta     int // Positive in favor of the attacker
+	RegimentsLost  int // By the losing side
 }
 
 type DatabaseWar struct {
-	Attacker      string
-	Defender      string
-	Score         int
-	ID            string
-	TerritoryName string
-	IsOngoing     bool
-	StartYear     int
+	Attacker           string
+	Defender           string
+	Score              int
+	ID                 string
+	TerritoryName      string
+	IsOngoing          bool
+	StartYear          int
+	Battles            []DatabaseBattle
+	AttackerAllies     []string
+	DefenderAllies     []string
+	Outcome            string // Why the war finished
+	Victor             string // Empty when the war finished without a victor
+	CeasefireUntilYear int    // No battles are fought up to and including this year
+	Occasion           Occasion
+	LiberatedFor       string // The nation a war of liberation returns the territory to
+	AttackerFunding    int    // Spent on the next battle and then used up
+	DefenderFunding    int
+}
+
+// Claimant is the nation that takes the territory if the attackers win
+func (war DatabaseWar) Claimant() string {
+	if len(war.LiberatedFor) != 0 {
+		return war.LiberatedFor
+	}
+	return war.Attacker
+}
+
+const OutcomeVictory = "Victory"
+const OutcomeWhitePeace = "White Peace"
+const OutcomeSurrender = "Surrender"
+
+const AttackingSide = "attacker"
+const DefendingSide = "defender"
+
+func (war DatabaseWar) GetAttackers() []string {
+	return append([]string{war.Attacker}, war.AttackerAllies...)
+}
+
+func (war DatabaseWar) GetDefenders() []string {
+	return append([]string{war.Defender}, war.DefenderAllies...)
+}
+
+// GetSide is AttackingSide or DefendingSide for nations fighting the war, including allies that joined it, and empty for everyone else
+func (war DatabaseWar) GetSide(nationID string) string {
+	if nationID == war.Attacker || containsNation(war.AttackerAllies, nationID) {
+		return AttackingSide
+	}
+
+	if nationID == war.Defender || containsNation(war.DefenderAllies, nationID) {
+		return DefendingSide
+	}
+
+	return ""
+}
+
+func (war DatabaseWar) IsInCeasefire(year int) bool {
+	return war.CeasefireUntilYear != 0 && year <= war.CeasefireUntilYear
+}
+
+func (war DatabaseWar) IsParticipant(nationID string) bool {
+	for _, participant := range append(war.GetAttackers(), war.GetDefenders()...) {
+		if participant == nationID {
+			return true
+		}
+	}
+	return false
 }
 
 func NewWar(attacker string, defender string, id string, territoryName string, startYear int) DatabaseWar {
-	return DatabaseWar{Attacker: attacker, Defender: defender, Score: 0, ID: id, TerritoryName: territoryName, IsOngoing: true, StartYear: startYear}
+	return DatabaseWar{Attacker: attacker, Defender: defender, Score: 0, ID: id, TerritoryName: territoryName, IsOngoing: true, StartYear: startYear, Battles: []DatabaseBattle{}, AttackerAllies: []string{}, DefenderAllies: []string{}}
+}
+
+type DatabaseCensusWeight struct {
+	Scale  int
+	Weight int
 }
 
 type DatabaseMap struct {
-	ID    string
-	Name  string
-	Year  int
-	Cells map[string]DatabaseCell
-	Wars  map[string]DatabaseWar
+	ID                      string
+	Name                    string
+	Year                    int
+	Cells                   map[string]DatabaseCell
+	Wars                    map[string]DatabaseWar
+	Version                 int
+	Participants            []string // Every nation that has held a territory on the map, in the order they first held one
+	Seed                    int64
+	LayoutID                string          // Which board the map is played on
+	GeneratedLayout         *DatabaseLayout // Only set for maps played on a generated board
+	Alliances               map[string]DatabaseAlliance
+	PeaceOffers             map[string]DatabasePeaceOffer // Keyed by war ID since a war has at most one offer on the table
+	CensusWeights           []DatabaseCensusWeight        // How much each census scale counts towards a nation's strength in battle
+	Treasuries              map[string]int
+	Armies                  map[string]DatabaseArmy
+	NextArmyNumber          int
+	OrderPhaseHours         int // How long players have to give orders on maps without a scheduled cadence
+	TickCadence             Cadence
+	YearStartedAt           time.Time
+	NextTickAtUnixSeconds   int64                  `dynamodbav:",omitempty"` // When the scheduler has to end the year. Zero on manual and finished maps.
+	Orders                  []DatabaseOrder        // Given this year and carried out when it resolves
+	OrdersSubmitted         []string               // Players who have finished giving orders for the current year
+	DroppedOrders           []DatabaseDroppedOrder // The orders that couldn't be carried out when the last year resolved
+	VictoryConditions       []VictoryCondition
+	VictoryTerritoryPercent int
+	VictoryYearLimit        int
+	IsFinished              bool
+	FinishedYear            int
+	Winners                 []string
+	VictoryReason           VictoryCondition
+}
+
+// VersionConflictError is returned when saving a map that someone else saved since it was read
+type VersionConflictError struct {
+	MapID string
+}
+
+func (err VersionConflictError) Error() string {
+	return fmt.Sprintf("Someone else changed map %s at the same time. Try again.", err.MapID)
+}
+
+func IsVersionConflict(err error) bool {
+	return errors.As(err, &VersionConflictError{})
 }
 
 func NewBlankDatabaseMap() DatabaseMap {
 	return DatabaseMap{
-		Cells: make(map[string]DatabaseCell),
-		Wars:  make(map[string]DatabaseWar),
+		Cells:           make(map[string]DatabaseCell),
+		Wars:            make(map[string]DatabaseWar),
+		Participants:    []string{},
+		Alliances:       make(map[string]DatabaseAlliance),
+		PeaceOffers:     make(map[string]DatabasePeaceOffer),
+		Treasuries:      make(map[string]int),
+		Armies:          make(map[string]DatabaseArmy),
+		OrderPhaseHours: DefaultOrderPhaseHours,
+		TickCadence:     ManualCadence,
+		Orders:          []DatabaseOrder{},
+		OrdersSubmitted: []string{},
+		DroppedOrders:   []DatabaseDroppedOrder{},
+	}
+}
+
+// Copy is deep so changes can be tried out on it without touching the original
+func (databaseMap DatabaseMap) Copy() (DatabaseMap, error) {
+	mapBytes, err := json.Marshal(databaseMap)
+	if err != nil {
+		return NewBlankDatabaseMap(), err
+	}
+
+	copiedMap := NewBlankDatabaseMap()
+	err = json.Unmarshal(mapBytes, &copiedMap)
+	if err != nil {
+		return NewBlankDatabaseMap(), err
 	}
+
+	return copiedMap, nil
 }
 
 func NewDatabaseMapWithTerritories(territoryIDs []string) DatabaseMap {
@@ -45,6 +230,65 @@ func NewDatabaseMapWithTerritories(territoryIDs []string) DatabaseMap {
 	return databaseMap
 }
 
+// Normalize brings a map saved by an older version of the game up to date so nothing else has to handle what it's missing
+func (databaseMap *DatabaseMap) Normalize() {
+	if databaseMap.Cells == nil {
+		databaseMap.Cells = make(map[string]DatabaseCell)
+	}
+	if databaseMap.Wars == nil {
+		databaseMap.Wars = make(map[string]DatabaseWar)
+	}
+	if databaseMap.Participants == nil {
+		databaseMap.Participants = []string{}
+	}
+	if databaseMap.Alliances == nil {
+		databaseMap.Alliances = make(map[string]DatabaseAlliance)
+	}
+	if databaseMap.PeaceOffers == nil {
+		databaseMap.PeaceOffers = make(map[string]DatabasePeaceOffer)
+	}
+	if databaseMap.Treasuries == nil {
+		databaseMap.Treasuries = make(map[string]int)
+	}
+	if databaseMap.Armies == nil {
+		databaseMap.Armies = make(map[string]DatabaseArmy)
+	}
+	if databaseMap.Orders == nil {
+		databaseMap.Orders = []DatabaseOrder{}
+	}
+	if databaseMap.OrdersSubmitted == nil {
+		databaseMap.OrdersSubmitted = []string{}
+	}
+	if databaseMap.DroppedOrders == nil {
+		databaseMap.DroppedOrders = []DatabaseDroppedOrder{}
+	}
+	if databaseMap.OrderPhaseHours == 0 {
+		databaseMap.OrderPhaseHours = DefaultOrderPhaseHours
+	}
+	if len(databaseMap.TickCadence) == 0 {
+		databaseMap.TickCadence = ManualCadence
+	}
+
+	for _, territoryID := range databaseMap.GetCellIDs() {
+		territory := databaseMap.Cells[territoryID]
+
+		if len(territory.OriginalResident) == 0 {
+			if len(territory.FormerResidents) != 0 {
+				territory.OriginalResident = territory.FormerResidents[0]
+			} else {
+				territory.OriginalResident = territory.Resident
+			}
+			databaseMap.Cells[territoryID] = territory
+		}
+
+		for _, nationID := range append(territory.FormerResidents, territory.Resident) {
+			if len(nationID) != 0 && !databaseMap.HasParticipant(nationID) {
+				databaseMap.Participants = append(databaseMap.Participants, nationID)
+			}
+		}
+	}
+}
+
 func (databaseMap *DatabaseMap) SetResident(territoryName string, nationID string) error {
 
 	territory, doesCellExist := databaseMap.Cells[territoryName]
@@ -52,9 +296,30 @@ func (databaseMap *DatabaseMap) SetResident(territoryName string, nationID strin
 		return errors.New("Territory doesn't exist")
 	}
 
+	if len(territory.Resident) != 0 && territory.Resident != nationID {
+		territory.FormerResidents = append(territory.FormerResidents, territory.Resident)
+
+		// Conquest damages the fortifications
+		if territory.FortificationLevel > 0 {
+			territory.FortificationLevel--
+		}
+	}
+
+	if len(territory.Resident) == 0 && len(territory.FormerResidents) == 0 && len(territory.OriginalResident) == 0 {
+		territory.OriginalResident = nationID
+	}
+
+	if territory.Resident != nationID {
+		territory.HeldSinceYear = databaseMap.Year
+	}
+
 	territory.Resident = nationID
 	databaseMap.Cells[territoryName] = territory
 
+	if len(nationID) != 0 && !databaseMap.HasParticipant(nationID) {
+		databaseMap.Participants = append(databaseMap.Participants, nationID)
+	}
+
 	return nil
 }
 
@@ -78,6 +343,45 @@ func (databaseMap DatabaseMap) HasResident(territoryName string) (bool, error) {
 	return len(resident) != 0, nil
 }
 
+func (databaseMap DatabaseMap) HasParticipant(nationID string) bool {
+	for _, participant := range databaseMap.Participants {
+		if participant == nationID {
+			return true
+		}
+	}
+	return false
+}
+
+// ParticipantsMissingFrom are the nations taking part in this map that don't take part in the other version of it
+func (databaseMap DatabaseMap) ParticipantsMissingFrom(otherMap DatabaseMap) []string {
+	missingParticipants := []string{}
+	for _, participant := range databaseMap.Participants {
+		if !otherMap.HasParticipant(participant) {
+			missingParticipants = append(missingParticipants, participant)
+		}
+	}
+	return missingParticipants
+}
+
+func (databaseMap DatabaseMap) HoldsAnyTerritory(nationID string) bool {
+	for _, cell := range databaseMap.Cells {
+		if cell.Resident == nationID {
+			return true
+		}
+	}
+	return false
+}
+
+func (databaseMap DatabaseMap) GetCellIDs() []string {
+	cellIDs := make([]string, 0, len(databaseMap.Cells))
+	for cellID := range databaseMap.Cells {
+		cellIDs = append(cellIDs, cellID)
+	}
+	sort.Strings(cellIDs)
+	return cellIDs
+}
+
+// GetWars is sorted by ID so anything that draws random numbers for each war does so in a repeatable order
 func (databaseMap DatabaseMap) GetWars() []DatabaseWar {
 
 	warsToReturn := make([]DatabaseWar, 0, len(databaseMap.Wars))
@@ -85,9 +389,20 @@ func (databaseMap DatabaseMap) GetWars() []DatabaseWar {
 		warsToReturn = append(warsToReturn, war)
 	}
 
+	sort.Slice(warsToReturn, func(i, j int) bool {
+		return warsToReturn[i].ID < warsToReturn[j].ID
+	})
+
 	return warsToReturn
 }
 
+const yearSeedMultiplier = 1000003
+
+// NewRandomForYear gives the same random numbers every time the map's current year is simulated so any year can be replayed from its snapshot
+func (databaseMap DatabaseMap) NewRandomForYear() *rand.Rand {
+	return rand.New(rand.NewSource(databaseMap.Seed + int64(databaseMap.Year)*yearSeedMultiplier))
+}
+
 func (databaseMap DatabaseMap) PutWars(warsToAdd []DatabaseWar) {
 
 	for _, warToAdd := range warsToAdd {
@@ -0,0 +1,95 @@
+package databasemap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettingAResidentAddsAParticipant(t *testing.T) {
+
+	databaseMap := NewDatabaseMapWithTerritories([]string{"A", "B"})
+	assert.Empty(t, databaseMap.Participants)
+
+	databaseMap.SetResident("A", "nation1")
+	databaseMap.SetResident("B", "nation2")
+	databaseMap.SetResident("B", "nation1")
+
+	assert.Equal(t, []string{"nation1", "nation2"}, databaseMap.Participants)
+	assert.True(t, databaseMap.HasParticipant("nation2"))
+	assert.False(t, databaseMap.HasParticipant("nation3"))
+}
+
+func TestClearingAResidentDoesntAddAParticipant(t *testing.T) {
+
+	databaseMap := NewDatabaseMapWithTerritories([]string{"A"})
+	databaseMap.SetResident("A", "")
+
+	assert.Empty(t, databaseMap.Participants)
+}
+
+func TestSettingAResidentOfAMissingTerritoryIsAnError(t *testing.T) {
+
+	databaseMap := NewDatabaseMapWithTerritories([]string{"A"})
+
+	assert.Error(t, databaseMap.SetResident("B", "nation1"))
+	assert.Empty(t, databaseMap.Participants)
+}
+
+func TestChangingAResidentRemembersTheFormerResident(t *testing.T) {
+
+	databaseMap := NewDatabaseMapWithTerritories([]string{"A"})
+	databaseMap.SetResident("A", "original")
+	assert.Empty(t, databaseMap.Cells["A"].FormerResident())
+
+	databaseMap.SetResident("A", "occupier")
+	databaseMap.SetResident("A", "occupier")
+
+	territory := databaseMap.Cells["A"]
+	assert.Equal(t, []string{"original"}, territory.FormerResidents)
+	assert.Equal(t, "original", territory.FormerResident())
+	assert.True(t, territory.WasHeldBy("original"))
+	assert.False(t, territory.WasHeldBy("occupier"))
+}
+
+func TestTerritoriesRememberTheirOriginalResidentAndWhenTheyChangedHands(t *testing.T) {
+
+	databaseMap := NewDatabaseMapWithTerritories([]string{"A"})
+	databaseMap.SetResident("A", "nation1")
+
+	databaseMap.Year = 4
+	databaseMap.SetResident("A", "nation2")
+	databaseMap.Year = 6
+	databaseMap.SetResident("A", "nation2")
+
+	territory := databaseMap.Cells["A"]
+	assert.Equal(t, "nation1", territory.OriginalResident)
+	assert.Equal(t, 4, territory.HeldSinceYear)
+	assert.True(t, territory.IsOccupied())
+
+	databaseMap.SetResident("A", "nation1")
+	assert.False(t, databaseMap.Cells["A"].IsOccupied())
+}
+
+func TestNormalizingAnOldMapFillsInWhatItWasSavedWithout(t *testing.T) {
+
+	databaseMap := DatabaseMap{Cells: map[string]DatabaseCell{
+		"A": {ID: "A", Resident: "nation3", FormerResidents: []string{"nation1", "nation2"}},
+		"B": {ID: "B", Resident: "nation4"},
+		"C": {ID: "C"},
+	}}
+
+	databaseMap.Normalize()
+
+	assert.Equal(t, "nation1", databaseMap.Cells["A"].OriginalResident)
+	assert.Equal(t, "nation4", databaseMap.Cells["B"].OriginalResident)
+	assert.Empty(t, databaseMap.Cells["C"].OriginalResident)
+	assert.Equal(t, []string{"nation1", "nation2", "nation3", "nation4"}, databaseMap.Participants)
+	assert.NotNil(t, databaseMap.Wars)
+	assert.NotNil(t, databaseMap.Alliances)
+	assert.NotNil(t, databaseMap.PeaceOffers)
+	assert.NotNil(t, databaseMap.Treasuries)
+	assert.NotNil(t, databaseMap.Armies)
+	assert.Equal(t, ManualCadence, databaseMap.TickCadence)
+	assert.Equal(t, DefaultOrderPhaseHours, databaseMap.OrderPhaseHours)
+}
@@ -0,0 +1,131 @@
+package databasemap
+
+import (
+	"errors"
+	"fmt"
+)
+
+const RecruitCost = 10
+const RegimentUpkeep = 1
+const FortifyCost = 15
+
+func TerrainIncome(terrain Terrain) int {
+	switch terrain {
+	case Forest, Hills:
+		return 2
+	case Mountains, Marsh:
+		return 1
+	}
+	return 3
+}
+
+// YearlyIncome falls back to the terrain's yield for territories created before income was stored
+func (cell DatabaseCell) YearlyIncome() int {
+	if cell.Income != 0 {
+		return cell.Income
+	}
+	return TerrainIncome(cell.Terrain)
+}
+
+func (databaseMap DatabaseMap) GetTreasury(nationID string) int {
+	return databaseMap.Treasuries[nationID]
+}
+
+func (databaseMap DatabaseMap) GetIncome(nationID string) int {
+	income := 0
+	for _, cell := range databaseMap.Cells {
+		if cell.Resident == nationID {
+			income += cell.YearlyIncome()
+		}
+	}
+	return income
+}
+
+func (databaseMap *DatabaseMap) spend(nationID string, amount int) error {
+	if databaseMap.GetTreasury(nationID) < amount {
+		return fmt.Errorf("You can't afford that. It costs %d gold and you have %d.", amount, databaseMap.GetTreasury(nationID))
+	}
+
+	databaseMap.Treasuries[nationID] -= amount
+
+	return nil
+}
+
+// CollectIncome pays each nation for its territories and disbands the regiments it can't pay upkeep for
+func (databaseMap *DatabaseMap) CollectIncome() {
+
+	for _, cell := range databaseMap.Cells {
+		if len(cell.Resident) != 0 {
+			databaseMap.Treasuries[cell.Resident] += cell.YearlyIncome()
+		}
+	}
+
+	for _, nationID := range databaseMap.Participants {
+		regiments := databaseMap.GetRegiments(nationID)
+		affordableRegiments := databaseMap.Treasuries[nationID] / RegimentUpkeep
+		if regiments > affordableRegiments {
+			databaseMap.removeRegiments(nationID, regiments-affordableRegiments)
+			regiments = affordableRegiments
+		}
+
+		databaseMap.Treasuries[nationID] -= regiments * RegimentUpkeep
+	}
+}
+
+// Recruit raises a regiment in one of the nation's territories
+func (databaseMap *DatabaseMap) Recruit(nationID string, territoryID string) error {
+	err := databaseMap.CheckNotFinished()
+	if err != nil {
+		return err
+	}
+
+	territory, doesTerritoryExist := databaseMap.Cells[territoryID]
+	if !doesTerritoryExist || territory.Resident != nationID {
+		return errors.New("You can only recruit regiments in a territory you control")
+	}
+
+	err = databaseMap.spend(nationID, RecruitCost)
+	if err != nil {
+		return err
+	}
+
+	databaseMap.addRegiment(nationID, territoryID)
+
+	return nil
+}
+
+// FundWar pays for extra forces in the next battle of a war the nation is fighting
+func (databaseMap *DatabaseMap) FundWar(warID string, nationID string, amount int) error {
+	err := databaseMap.CheckNotFinished()
+	if err != nil {
+		return err
+	}
+
+	if amount <= 0 {
+		return errors.New("You must spend some gold to fund a war")
+	}
+
+	war, doesWarExist := databaseMap.Wars[warID]
+	if !doesWarExist || !war.IsOngoing {
+		return errors.New("That war isn't being fought")
+	}
+
+	side := war.GetSide(nationID)
+	if len(side) == 0 {
+		return errors.New("You can only fund a war you're fighting in")
+	}
+
+	err = databaseMap.spend(nationID, amount)
+	if err != nil {
+		return err
+	}
+
+	if side == AttackingSide {
+		war.AttackerFunding += amount
+	} else {
+		war.DefenderFunding += amount
+	}
+	databaseMap.Wars[warID] = war
+
+	return nil
+}
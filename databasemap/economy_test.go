@@ -0,0 +1,118 @@
+package databasemap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func makeEconomyTestMap() DatabaseMap {
+	databaseMap := NewDatabaseMapWithTerritories([]string{"A", "B", "C"})
+	databaseMap.Cells["A"] = DatabaseCell{ID: "A", Terrain: Plains, Income: 3}
+	databaseMap.Cells["B"] = DatabaseCell{ID: "B", Terrain: Mountains}
+	databaseMap.SetResident("A", "nation1")
+	databaseMap.SetResident("B", "nation1")
+	databaseMap.SetResident("C", "nation2")
+	return databaseMap
+}
+
+func TestCollectingIncomePaysForEveryTerritoryHeld(t *testing.T) {
+
+	databaseMap := makeEconomyTestMap()
+	assert.Equal(t, 3+TerrainIncome(Mountains), databaseMap.GetIncome("nation1"))
+
+	databaseMap.CollectIncome()
+	databaseMap.CollectIncome()
+
+	assert.Equal(t, 2*(3+TerrainIncome(Mountains)), databaseMap.GetTreasury("nation1"))
+	assert.Equal(t, 2*TerrainIncome(Plains), databaseMap.GetTreasury("nation2"))
+}
+
+func TestRecruitingCostsGoldAndRegimentsNeedUpkeep(t *testing.T) {
+
+	databaseMap := makeEconomyTestMap()
+	assert.Error(t, databaseMap.Recruit("nation1", "A"))
+
+	databaseMap.Treasuries["nation1"] = RecruitCost
+	assert.NoError(t, databaseMap.Recruit("nation1", "A"))
+	assert.Equal(t, 1, databaseMap.GetRegiments("nation1"))
+	assert.Equal(t, 0, databaseMap.GetTreasury("nation1"))
+
+	databaseMap.CollectIncome()
+	assert.Equal(t, databaseMap.GetIncome("nation1")-RegimentUpkeep, databaseMap.GetTreasury("nation1"))
+}
+
+func TestRegimentsThatCantBePaidDisband(t *testing.T) {
+
+	databaseMap := makeEconomyTestMap()
+	for regiment := 0; regiment < TerrainIncome(Plains)+2; regiment++ {
+		databaseMap.addRegiment("nation2", "C")
+	}
+
+	databaseMap.CollectIncome()
+
+	assert.Equal(t, TerrainIncome(Plains)/RegimentUpkeep, databaseMap.GetRegiments("nation2"))
+	assert.Equal(t, 0, databaseMap.GetTreasury("nation2"))
+}
+
+func TestRegimentsCanOnlyBeRecruitedInYourOwnTerritory(t *testing.T) {
+
+	databaseMap := makeEconomyTestMap()
+	databaseMap.Treasuries["nation1"] = RecruitCost
+
+	assert.Error(t, databaseMap.Recruit("nation1", "C"))
+	assert.Error(t, databaseMap.Recruit("nation1", "missing"))
+	assert.Equal(t, RecruitCost, databaseMap.GetTreasury("nation1"))
+}
+
+func TestFundingAWarGoesToTheFundersSide(t *testing.T) {
+
+	databaseMap := makeEconomyTestMap()
+	databaseMap.PutWars([]DatabaseWar{NewWar("nation2", "nation1", "warForA", "A", 0)})
+	databaseMap.Treasuries["nation1"] = 20
+	databaseMap.Treasuries["nation2"] = 20
+
+	assert.Error(t, databaseMap.FundWar("warForA", "nation1", 0))
+	assert.Error(t, databaseMap.FundWar("warForA", "nation1", 21))
+	assert.Error(t, databaseMap.FundWar("warForA", "nation3", 1))
+
+	assert.NoError(t, databaseMap.FundWar("warForA", "nation1", 15))
+	assert.NoError(t, databaseMap.FundWar("warForA", "nation2", 5))
+
+	theWar := databaseMap.Wars["warForA"]
+	assert.Equal(t, 5, theWar.AttackerFunding)
+	assert.Equal(t, 15, theWar.DefenderFunding)
+	assert.Equal(t, 5, databaseMap.GetTreasury("nation1"))
+	assert.Equal(t, 15, databaseMap.GetTreasury("nation2"))
+}
+
+func TestFundingFromAnAllyGoesToTheSideItJoined(t *testing.T) {
+
+	databaseMap := makeEconomyTestMap()
+	theWar := NewWar("nation2", "nation1", "warForA", "A", 0)
+	theWar.AttackerAllies = []string{"nation3"}
+	theWar.DefenderAllies = []string{"nation4"}
+	databaseMap.PutWars([]DatabaseWar{theWar})
+	databaseMap.Treasuries["nation3"] = 20
+	databaseMap.Treasuries["nation4"] = 20
+
+	assert.NoError(t, databaseMap.FundWar("warForA", "nation3", 7))
+	assert.NoError(t, databaseMap.FundWar("warForA", "nation4", 4))
+
+	theWar = databaseMap.Wars["warForA"]
+	assert.Equal(t, 7, theWar.AttackerFunding)
+	assert.Equal(t, 4, theWar.DefenderFunding)
+	assert.Equal(t, 13, databaseMap.GetTreasury("nation3"))
+	assert.Equal(t, 16, databaseMap.GetTreasury("nation4"))
+}
+
+func TestAMapSavedWithoutAnEconomyCanCollectIncome(t *testing.T) {
+
+	databaseMap := makeEconomyTestMap()
+	databaseMap.Treasuries = nil
+	databaseMap.Normalize()
+
+	assert.Equal(t, 0, databaseMap.GetTreasury("nation1"))
+	databaseMap.CollectIncome()
+	assert.NotZero(t, databaseMap.GetTreasury("nation1"))
+}
@@ -0,0 +1,28 @@
+package databasemap
+
+// DatabaseLayout is a generated board kept with the map played on it so the map never depends on the generator staying the same
+type DatabaseLayout struct {
+	Name        string
+	WidthPX     int
+	HeightPX    int
+	Territories []DatabaseLayoutTerritory
+	Borders     []DatabaseBorder
+}
+
+type DatabaseLayoutTerritory struct {
+	ID      string
+	LeftPX  int
+	TopPX   int
+	Terrain Terrain
+	Outline []DatabasePoint
+}
+
+type DatabaseBorder struct {
+	A string
+	B string
+}
+
+type DatabasePoint struct {
+	X float64
+	Y float64
+}
@@ -0,0 +1,47 @@
+package databasemap
+
+// Occasion is the casus belli a war was declared under
+type Occasion string
+
+const (
+	Conquest   Occasion = "conquest"
+	HolyWar    Occasion = "holy_war"
+	Liberation Occasion = "liberation"
+	Reconquest Occasion = "reconquest"
+)
+
+var Occasions = []Occasion{Conquest, HolyWar, Liberation, Reconquest}
+
+func (occasion Occasion) IsValid() bool {
+	for _, validOccasion := range Occasions {
+		if occasion == validOccasion {
+			return true
+		}
+	}
+	return false
+}
+
+func (occasion Occasion) DisplayName() string {
+	switch occasion {
+	case HolyWar:
+		return "Holy War"
+	case Liberation:
+		return "Liberation"
+	case Reconquest:
+		return "Reconquest"
+	}
+	return "Conquest"
+}
+
+// WarNamePhrase is the part of a war's name between the attacker's demonym and the territory
+func (occasion Occasion) WarNamePhrase() string {
+	switch occasion {
+	case HolyWar:
+		return "Holy War for"
+	case Liberation:
+		return "Liberation of"
+	case Reconquest:
+		return "Reconquest of"
+	}
+	return "Conquest of"
+}
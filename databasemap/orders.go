@@ -0,0 +1,227 @@
+package databasemap
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const DefaultOrderPhaseHours = 24
+const MaximumOrderPhaseHours = 24 * 7
+
+type OrderKind string
+
+const (
+	DeclareWarOrder      OrderKind = "declare_war"
+	JoinWarOrder         OrderKind = "join_war"
+	FundWarOrder         OrderKind = "fund_war"
+	RecruitOrder         OrderKind = "recruit"
+	MoveArmyOrder        OrderKind = "move_army"
+	FortifyOrder         OrderKind = "fortify"
+	ColoniseOrder        OrderKind = "colonise"
+	OfferPeaceOrder      OrderKind = "offer_peace"
+	AcceptPeaceOrder     OrderKind = "accept_peace"
+	RejectPeaceOrder     OrderKind = "reject_peace"
+	ProposeAllianceOrder OrderKind = "propose_alliance"
+	AcceptAllianceOrder  OrderKind = "accept_alliance"
+	BreakAllianceOrder   OrderKind = "break_alliance"
+)
+
+// orderKindsInResolvingOrder is when each kind of order is carried out as a year resolves so it doesn't matter which nation gave its orders first
+var orderKindsInResolvingOrder = []OrderKind{
+	AcceptAllianceOrder,
+	AcceptPeaceOrder,
+	RejectPeaceOrder,
+	BreakAllianceOrder,
+	ProposeAllianceOrder,
+	OfferPeaceOrder,
+	DeclareWarOrder,
+	JoinWarOrder,
+	ColoniseOrder,
+	FundWarOrder,
+	RecruitOrder,
+	FortifyOrder,
+	MoveArmyOrder,
+}
+
+// DatabaseOrder only has the fields its kind needs set
+type DatabaseOrder struct {
+	Nation         string
+	Kind           OrderKind
+	TerritoryID    string
+	WarID          string
+	ArmyID         string
+	OtherNation    string
+	Occasion       Occasion
+	Side           string
+	Terms          string
+	CeasefireYears int
+	Amount         int
+}
+
+// DatabaseDroppedOrder is an order that couldn't be carried out when its year resolved
+type DatabaseDroppedOrder struct {
+	Order  DatabaseOrder
+	Reason string
+}
+
+// isClaim is true for orders that take a territory, which other nations can contest
+func (order DatabaseOrder) isClaim() bool {
+	return order.Kind == ColoniseOrder || order.Kind == DeclareWarOrder
+}
+
+func resolvingRank(kind OrderKind) int {
+	for rank, rankedKind := range orderKindsInResolvingOrder {
+		if rankedKind == kind {
+			return rank
+		}
+	}
+	return len(orderKindsInResolvingOrder)
+}
+
+// SortOrdersForResolving keeps the orders of each kind in the order they were given
+func SortOrdersForResolving(orders []DatabaseOrder) []DatabaseOrder {
+	sortedOrders := append([]DatabaseOrder{}, orders...)
+	sort.SliceStable(sortedOrders, func(i, j int) bool {
+		return resolvingRank(sortedOrders[i].Kind) < resolvingRank(sortedOrders[j].Kind)
+	})
+	return sortedOrders
+}
+
+// SettleClaims decides contested claims by the regiments each nation has around the territory. Equal claims cancel each other out.
+func (databaseMap DatabaseMap) SettleClaims(orders []DatabaseOrder, areNeighbours AreNeighbours) ([]DatabaseOrder, []DatabaseDroppedOrder) {
+	claimsByTarget := make(map[string][]DatabaseOrder)
+	for _, order := range orders {
+		if order.isClaim() {
+			target := string(order.Kind) + ":" + order.TerritoryID
+			claimsByTarget[target] = append(claimsByTarget[target], order)
+		}
+	}
+
+	keptOrders := []DatabaseOrder{}
+	droppedOrders := []DatabaseDroppedOrder{}
+	for _, order := range orders {
+		if !order.isClaim() {
+			keptOrders = append(keptOrders, order)
+			continue
+		}
+
+		regiments := databaseMap.RegimentsAround(order.Nation, order.TerritoryID, areNeighbours)
+		strongestRivalRegiments := -1
+		for _, rivalClaim := range claimsByTarget[string(order.Kind)+":"+order.TerritoryID] {
+			rivalRegiments := databaseMap.RegimentsAround(rivalClaim.Nation, order.TerritoryID, areNeighbours)
+			if rivalClaim.Nation != order.Nation && rivalRegiments > strongestRivalRegiments {
+				strongestRivalRegiments = rivalRegiments
+			}
+		}
+
+		territoryName := databaseMap.Cells[order.TerritoryID].DisplayName()
+		if regiments > strongestRivalRegiments {
+			keptOrders = append(keptOrders, order)
+		} else if regiments == strongestRivalRegiments {
+			droppedOrders = append(droppedOrders, DatabaseDroppedOrder{Order: order, Reason: fmt.Sprintf("Another nation with as many regiments around %s claimed it too so neither claim went ahead", territoryName)})
+		} else {
+			droppedOrders = append(droppedOrders, DatabaseDroppedOrder{Order: order, Reason: fmt.Sprintf("Another nation with more regiments around %s claimed it too", territoryName)})
+		}
+	}
+
+	return keptOrders, droppedOrders
+}
+
+// GetPlayers are the nations holding at least one territory, in the order they joined the map
+func (databaseMap DatabaseMap) GetPlayers() []string {
+	players := []string{}
+	for _, participant := range databaseMap.Participants {
+		for _, cell := range databaseMap.Cells {
+			if cell.Resident == participant {
+				players = append(players, participant)
+				break
+			}
+		}
+	}
+	return players
+}
+
+func (databaseMap DatabaseMap) IsPlayer(nationID string) bool {
+	return containsNation(databaseMap.GetPlayers(), nationID)
+}
+
+func (databaseMap DatabaseMap) HasSubmittedOrders(nationID string) bool {
+	return containsNation(databaseMap.OrdersSubmitted, nationID)
+}
+
+// OrdersDeadline is when the year resolves even if some players haven't submitted their orders
+func (databaseMap DatabaseMap) OrdersDeadline() time.Time {
+	if databaseMap.TickCadence.IsScheduled() {
+		return databaseMap.TickCadence.NextTickAfter(databaseMap.YearStartedAt)
+	}
+
+	return databaseMap.YearStartedAt.Add(time.Duration(databaseMap.OrderPhaseHours) * time.Hour)
+}
+
+// AwaitingOrdersFrom never includes nations that give their own orders, such as AI players
+func (databaseMap DatabaseMap) AwaitingOrdersFrom(selfOrderingNationIDs []string) []string {
+	awaitedNationIDs := []string{}
+	for _, player := range databaseMap.GetPlayers() {
+		if !databaseMap.HasSubmittedOrders(player) && !containsNation(selfOrderingNationIDs, player) {
+			awaitedNationIDs = append(awaitedNationIDs, player)
+		}
+	}
+	return awaitedNationIDs
+}
+
+func (databaseMap DatabaseMap) IsReadyToResolve(now time.Time, selfOrderingNationIDs []string) bool {
+	return len(databaseMap.AwaitingOrdersFrom(selfOrderingNationIDs)) == 0 || !now.Before(databaseMap.OrdersDeadline())
+}
+
+// SubmitOrders records that the nation has finished giving orders for the year
+func (databaseMap *DatabaseMap) SubmitOrders(nationID string) error {
+	err := databaseMap.CheckNotFinished()
+	if err != nil {
+		return err
+	}
+
+	if !databaseMap.IsPlayer(nationID) {
+		return errors.New("Only nations holding a territory on this map give orders")
+	}
+
+	if databaseMap.HasSubmittedOrders(nationID) {
+		return errors.New("You've already submitted your orders for this year")
+	}
+
+	databaseMap.OrdersSubmitted = append(databaseMap.OrdersSubmitted, nationID)
+
+	return nil
+}
+
+// StartOrderPhase opens the current year for orders
+func (databaseMap *DatabaseMap) StartOrderPhase(now time.Time) {
+	databaseMap.Orders = []DatabaseOrder{}
+	databaseMap.OrdersSubmitted = []string{}
+	databaseMap.YearStartedAt = now
+}
+
+func (databaseMap DatabaseMap) CheckCanGiveOrders(nationID string) error {
+	err := databaseMap.CheckNotFinished()
+	if err != nil {
+		return err
+	}
+
+	if databaseMap.HasSubmittedOrders(nationID) {
+		return errors.New("You've already submitted your orders for this year")
+	}
+
+	return nil
+}
+
+// GetOrdersOf are the orders the nation has given this year in the order it gave them
+func (databaseMap DatabaseMap) GetOrdersOf(nationID string) []DatabaseOrder {
+	orders := []DatabaseOrder{}
+	for _, order := range databaseMap.Orders {
+		if order.Nation == nationID {
+			orders = append(orders, order)
+		}
+	}
+	return orders
+}
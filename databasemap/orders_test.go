@@ -0,0 +1,97 @@
+package databasemap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func makeOrdersTestMap() DatabaseMap {
+	databaseMap := NewDatabaseMapWithTerritories([]string{"A", "B", "C"})
+	databaseMap.SetResident("A", "nation1")
+	databaseMap.SetResident("B", "nation2")
+	databaseMap.SetResident("C", "empire")
+	databaseMap.StartOrderPhase(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC))
+	return databaseMap
+}
+
+func TestTheYearResolvesOnceEveryPlayerHasSubmittedOrders(t *testing.T) {
+
+	databaseMap := makeOrdersTestMap()
+	beforeTheDeadline := databaseMap.YearStartedAt.Add(time.Hour)
+
+	assert.NoError(t, databaseMap.SubmitOrders("nation1"))
+	assert.Equal(t, []string{"nation2"}, databaseMap.AwaitingOrdersFrom([]string{"empire"}))
+	assert.False(t, databaseMap.IsReadyToResolve(beforeTheDeadline, []string{"empire"}))
+
+	assert.NoError(t, databaseMap.SubmitOrders("nation2"))
+	assert.True(t, databaseMap.IsReadyToResolve(beforeTheDeadline, []string{"empire"}))
+	assert.False(t, databaseMap.IsReadyToResolve(beforeTheDeadline, []string{}))
+}
+
+func TestTheYearResolvesAtTheDeadlineWithoutEveryonesOrders(t *testing.T) {
+
+	databaseMap := makeOrdersTestMap()
+	databaseMap.OrderPhaseHours = 2
+
+	assert.False(t, databaseMap.IsReadyToResolve(databaseMap.YearStartedAt.Add(time.Hour), []string{}))
+	assert.True(t, databaseMap.IsReadyToResolve(databaseMap.YearStartedAt.Add(2*time.Hour), []string{}))
+}
+
+func TestAMapWithoutAnOrderPhaseLengthUsesTheDefault(t *testing.T) {
+
+	databaseMap := makeOrdersTestMap()
+
+	assert.Equal(t, databaseMap.YearStartedAt.Add(DefaultOrderPhaseHours*time.Hour), databaseMap.OrdersDeadline())
+}
+
+func TestOnlyPlayersSubmitOrdersAndOnlyOnceAYear(t *testing.T) {
+
+	databaseMap := makeOrdersTestMap()
+	databaseMap.SetResident("B", "nation1")
+
+	assert.Error(t, databaseMap.SubmitOrders("nation2"))
+	assert.Error(t, databaseMap.SubmitOrders("nation3"))
+
+	assert.NoError(t, databaseMap.SubmitOrders("nation1"))
+	assert.Error(t, databaseMap.SubmitOrders("nation1"))
+
+	databaseMap.StartOrderPhase(databaseMap.YearStartedAt.Add(time.Hour))
+	assert.NoError(t, databaseMap.SubmitOrders("nation1"))
+}
+
+func TestOrdersAreResolvedByKindAndThenInTheOrderTheyWereGiven(t *testing.T) {
+
+	orders := []DatabaseOrder{
+		{Nation: "nation1", Kind: MoveArmyOrder, ArmyID: "1"},
+		{Nation: "nation1", Kind: RecruitOrder, TerritoryID: "A"},
+		{Nation: "nation2", Kind: AcceptAllianceOrder, OtherNation: "nation1"},
+		{Nation: "nation1", Kind: RecruitOrder, TerritoryID: "B"},
+	}
+
+	assert.Equal(t, []DatabaseOrder{orders[2], orders[1], orders[3], orders[0]}, SortOrdersForResolving(orders))
+}
+
+func TestContestedClaimsGoToTheNationWithTheMostRegimentsAround(t *testing.T) {
+
+	databaseMap := NewDatabaseMapWithTerritories([]string{"A", "B", "C", "D"})
+	databaseMap.Armies["1"] = DatabaseArmy{ID: "1", Owner: "nation1", TerritoryID: "A", Regiments: 2}
+	databaseMap.Armies["2"] = DatabaseArmy{ID: "2", Owner: "nation2", TerritoryID: "C", Regiments: 1}
+
+	recruitOrder := DatabaseOrder{Nation: "nation2", Kind: RecruitOrder, TerritoryID: "C"}
+	weakerClaim := DatabaseOrder{Nation: "nation2", Kind: ColoniseOrder, TerritoryID: "B"}
+	strongerClaim := DatabaseOrder{Nation: "nation1", Kind: ColoniseOrder, TerritoryID: "B"}
+	uncontestedClaim := DatabaseOrder{Nation: "nation2", Kind: ColoniseOrder, TerritoryID: "D"}
+
+	keptOrders, droppedOrders := databaseMap.SettleClaims([]DatabaseOrder{recruitOrder, weakerClaim, strongerClaim, uncontestedClaim}, areNeighboursInALine)
+	assert.Equal(t, []DatabaseOrder{recruitOrder, strongerClaim, uncontestedClaim}, keptOrders)
+	assert.Len(t, droppedOrders, 1)
+	assert.Equal(t, weakerClaim, droppedOrders[0].Order)
+
+	databaseMap.Armies["2"] = DatabaseArmy{ID: "2", Owner: "nation2", TerritoryID: "C", Regiments: 2}
+
+	keptOrders, droppedOrders = databaseMap.SettleClaims([]DatabaseOrder{weakerClaim, strongerClaim}, areNeighboursInALine)
+	assert.Empty(t, keptOrders)
+	assert.Len(t, droppedOrders, 2)
+}
@@ -0,0 +1,158 @@
+package databasemap
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+const WhitePeaceTerms = "white_peace"
+const ConcedeTerritoryTerms = "concede_territory"
+const CeasefireTerms = "ceasefire"
+
+const MaximumCeasefireYears = 5
+
+type DatabasePeaceOffer struct {
+	WarID          string
+	From           string
+	To             string
+	Terms          string
+	CeasefireYears int
+	Year           int
+}
+
+func (offer DatabasePeaceOffer) Description() string {
+	switch offer.Terms {
+	case WhitePeaceTerms:
+		return "White peace, leaving the territory with its current resident"
+	case ConcedeTerritoryTerms:
+		return "Surrender, conceding the territory"
+	case CeasefireTerms:
+		return fmt.Sprintf("Ceasefire for %d years", offer.CeasefireYears)
+	}
+	return offer.Terms
+}
+
+func (databaseMap DatabaseMap) GetPeaceOffers() []DatabasePeaceOffer {
+	offers := make([]DatabasePeaceOffer, 0, len(databaseMap.PeaceOffers))
+	for _, offer := range databaseMap.PeaceOffers {
+		offers = append(offers, offer)
+	}
+
+	sort.Slice(offers, func(i, j int) bool {
+		return offers[i].WarID < offers[j].WarID
+	})
+
+	return offers
+}
+
+// OfferPeace puts terms to the opposing side, which only the war's attacker and defender can do
+func (databaseMap *DatabaseMap) OfferPeace(warID string, nationID string, terms string, ceasefireYears int) error {
+	err := databaseMap.CheckNotFinished()
+	if err != nil {
+		return err
+	}
+
+	war, doesWarExist := databaseMap.Wars[warID]
+	if !doesWarExist || !war.IsOngoing {
+		return errors.New("That war isn't being fought")
+	}
+
+	offer := DatabasePeaceOffer{
+		WarID: warID,
+		From:  nationID,
+		Terms: terms,
+		Year:  databaseMap.Year,
+	}
+
+	switch nationID {
+	case war.Attacker:
+		offer.To = war.Defender
+	case war.Defender:
+		offer.To = war.Attacker
+	default:
+		return errors.New("Only the leaders of a war can negotiate peace")
+	}
+
+	switch terms {
+	case WhitePeaceTerms, ConcedeTerritoryTerms:
+	case CeasefireTerms:
+		if ceasefireYears < 1 || ceasefireYears > MaximumCeasefireYears {
+			return fmt.Errorf("A ceasefire must last between 1 and %d years", MaximumCeasefireYears)
+		}
+		offer.CeasefireYears = ceasefireYears
+	default:
+		return errors.New("You didn't choose valid peace terms")
+	}
+
+	_, doesOfferExist := databaseMap.PeaceOffers[warID]
+	if doesOfferExist {
+		return errors.New("There are already peace terms on the table for that war")
+	}
+
+	databaseMap.PeaceOffers[warID] = offer
+
+	return nil
+}
+
+// RejectPeaceOffer also lets the nation that made the offer withdraw it
+func (databaseMap *DatabaseMap) RejectPeaceOffer(warID string, nationID string) error {
+	err := databaseMap.CheckNotFinished()
+	if err != nil {
+		return err
+	}
+
+	offer, doesOfferExist := databaseMap.PeaceOffers[warID]
+	if !doesOfferExist || (offer.From != nationID && offer.To != nationID) {
+		return errors.New("There are no peace terms for you in that war")
+	}
+
+	delete(databaseMap.PeaceOffers, warID)
+
+	return nil
+}
+
+func (databaseMap *DatabaseMap) AcceptPeaceOffer(warID string, nationID string) error {
+	err := databaseMap.CheckNotFinished()
+	if err != nil {
+		return err
+	}
+
+	offer, doesOfferExist := databaseMap.PeaceOffers[warID]
+	if !doesOfferExist || offer.To != nationID {
+		return errors.New("There are no peace terms for you in that war")
+	}
+
+	war, doesWarExist := databaseMap.Wars[warID]
+	if !doesWarExist || !war.IsOngoing {
+		return errors.New("That war isn't being fought")
+	}
+
+	switch offer.Terms {
+	case WhitePeaceTerms:
+		war.IsOngoing = false
+		war.Outcome = OutcomeWhitePeace
+	case ConcedeTerritoryTerms:
+		war.IsOngoing = false
+		war.Outcome = OutcomeSurrender
+		war.Victor = offer.To
+		if offer.To == war.Attacker {
+			err := databaseMap.SetResident(war.TerritoryName, war.Claimant())
+			if err != nil {
+				return err
+			}
+		}
+	case CeasefireTerms:
+		war.CeasefireUntilYear = databaseMap.Year + offer.CeasefireYears
+	}
+
+	databaseMap.Wars[warID] = war
+	delete(databaseMap.PeaceOffers, warID)
+
+	return nil
+}
+
+// ClearPeaceOffer drops any terms left on the table for a war that has finished
+func (databaseMap *DatabaseMap) ClearPeaceOffer(warID string) {
+	delete(databaseMap.PeaceOffers, warID)
+}
@@ -0,0 +1,95 @@
+package databasemap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func makePeaceTestMap() DatabaseMap {
+	databaseMap := NewDatabaseMapWithTerritories([]string{"A", "B"})
+	databaseMap.SetResident("A", "attacker")
+	databaseMap.SetResident("B", "defender")
+	databaseMap.PutWars([]DatabaseWar{NewWar("attacker", "defender", "warForB", "B", 0)})
+	return databaseMap
+}
+
+func TestAcceptingAWhitePeaceEndsTheWarWithoutAVictor(t *testing.T) {
+
+	databaseMap := makePeaceTestMap()
+
+	assert.NoError(t, databaseMap.OfferPeace("warForB", "attacker", WhitePeaceTerms, 0))
+	assert.Error(t, databaseMap.AcceptPeaceOffer("warForB", "attacker"))
+	assert.NoError(t, databaseMap.AcceptPeaceOffer("warForB", "defender"))
+
+	theWar := databaseMap.Wars["warForB"]
+	assert.False(t, theWar.IsOngoing)
+	assert.Equal(t, OutcomeWhitePeace, theWar.Outcome)
+	assert.Empty(t, theWar.Victor)
+	assert.Equal(t, "defender", databaseMap.Cells["B"].Resident)
+	assert.Empty(t, databaseMap.GetPeaceOffers())
+}
+
+func TestADefenderSurrenderingConcedesTheTerritory(t *testing.T) {
+
+	databaseMap := makePeaceTestMap()
+
+	assert.NoError(t, databaseMap.OfferPeace("warForB", "defender", ConcedeTerritoryTerms, 0))
+	assert.NoError(t, databaseMap.AcceptPeaceOffer("warForB", "attacker"))
+
+	theWar := databaseMap.Wars["warForB"]
+	assert.False(t, theWar.IsOngoing)
+	assert.Equal(t, OutcomeSurrender, theWar.Outcome)
+	assert.Equal(t, "attacker", theWar.Victor)
+	assert.Equal(t, "attacker", databaseMap.Cells["B"].Resident)
+}
+
+func TestAnAttackerSurrenderingLeavesTheTerritory(t *testing.T) {
+
+	databaseMap := makePeaceTestMap()
+
+	assert.NoError(t, databaseMap.OfferPeace("warForB", "attacker", ConcedeTerritoryTerms, 0))
+	assert.NoError(t, databaseMap.AcceptPeaceOffer("warForB", "defender"))
+
+	assert.Equal(t, "defender", databaseMap.Wars["warForB"].Victor)
+	assert.Equal(t, "defender", databaseMap.Cells["B"].Resident)
+}
+
+func TestAcceptingACeasefireKeepsTheWarGoing(t *testing.T) {
+
+	databaseMap := makePeaceTestMap()
+	databaseMap.Year = 3
+
+	assert.Error(t, databaseMap.OfferPeace("warForB", "attacker", CeasefireTerms, 0))
+	assert.Error(t, databaseMap.OfferPeace("warForB", "attacker", CeasefireTerms, MaximumCeasefireYears+1))
+	assert.NoError(t, databaseMap.OfferPeace("warForB", "attacker", CeasefireTerms, 2))
+	assert.NoError(t, databaseMap.AcceptPeaceOffer("warForB", "defender"))
+
+	theWar := databaseMap.Wars["warForB"]
+	assert.True(t, theWar.IsOngoing)
+	assert.Equal(t, 5, theWar.CeasefireUntilYear)
+	assert.True(t, theWar.IsInCeasefire(5))
+	assert.False(t, theWar.IsInCeasefire(6))
+}
+
+func TestOnlyOnePeaceOfferCanBeOnTheTable(t *testing.T) {
+
+	databaseMap := makePeaceTestMap()
+
+	assert.NoError(t, databaseMap.OfferPeace("warForB", "attacker", WhitePeaceTerms, 0))
+	assert.Error(t, databaseMap.OfferPeace("warForB", "defender", WhitePeaceTerms, 0))
+
+	assert.NoError(t, databaseMap.RejectPeaceOffer("warForB", "defender"))
+	assert.NoError(t, databaseMap.OfferPeace("warForB", "defender", WhitePeaceTerms, 0))
+	assert.NoError(t, databaseMap.RejectPeaceOffer("warForB", "defender"))
+	assert.Empty(t, databaseMap.GetPeaceOffers())
+}
+
+func TestOnlyTheLeadersOfAWarCanOfferPeace(t *testing.T) {
+
+	databaseMap := makePeaceTestMap()
+
+	assert.Error(t, databaseMap.OfferPeace("warForB", "bystander", WhitePeaceTerms, 0))
+	assert.Error(t, databaseMap.OfferPeace("warForB", "attacker", "unconditional", 0))
+	assert.Error(t, databaseMap.OfferPeace("missingWar", "attacker", WhitePeaceTerms, 0))
+}
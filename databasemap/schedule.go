@@ -0,0 +1,77 @@
+package databasemap
+
+import "time"
+
+// Cadence is how often the scheduler ends a map's year whether or not every player has submitted orders
+type Cadence string
+
+const (
+	ManualCadence      Cadence = "manual"
+	HourlyCadence      Cadence = "hourly"
+	DailyCadence       Cadence = "daily"
+	MajorUpdateCadence Cadence = "major_update"
+)
+
+var Cadences = []Cadence{ManualCadence, HourlyCadence, DailyCadence, MajorUpdateCadence}
+
+// The NationStates major update starts at midnight US Eastern time and takes a couple of hours so census data is fresh by this hour all year round
+const majorUpdateFinishedHourUTC = 7
+
+func (cadence Cadence) IsValid() bool {
+	for _, validCadence := range Cadences {
+		if cadence == validCadence {
+			return true
+		}
+	}
+	return false
+}
+
+// IsScheduled is false for maps that only advance when players end the year
+func (cadence Cadence) IsScheduled() bool {
+	return cadence != ManualCadence
+}
+
+func (cadence Cadence) DisplayName() string {
+	switch cadence {
+	case HourlyCadence:
+		return "Every hour"
+	case DailyCadence:
+		return "Every day at midnight UTC"
+	case MajorUpdateCadence:
+		return "After each NationStates major update"
+	}
+	return "Only when players end the year"
+}
+
+// NextTickAfter is the first scheduled time strictly after the given time
+func (cadence Cadence) NextTickAfter(after time.Time) time.Time {
+	after = after.UTC()
+	year, month, day := after.Date()
+
+	switch cadence {
+	case HourlyCadence:
+		return after.Truncate(time.Hour).Add(time.Hour)
+	case DailyCadence:
+		return time.Date(year, month, day+1, 0, 0, 0, 0, time.UTC)
+	case MajorUpdateCadence:
+		nextTick := time.Date(year, month, day, majorUpdateFinishedHourUTC, 0, 0, 0, time.UTC)
+		if !nextTick.After(after) {
+			nextTick = nextTick.AddDate(0, 0, 1)
+		}
+		return nextTick
+	}
+	return time.Time{}
+}
+
+// IsDueForScheduledTick is true once a scheduled map's year has reached its deadline
+func (databaseMap DatabaseMap) IsDueForScheduledTick(now time.Time) bool {
+	return databaseMap.TickCadence.IsScheduled() && !databaseMap.IsFinished && !now.Before(databaseMap.OrdersDeadline())
+}
+
+// ScheduleNextTick is called when the map is saved so the scheduler can find due maps without reading every map
+func (databaseMap *DatabaseMap) ScheduleNextTick() {
+	databaseMap.NextTickAtUnixSeconds = 0
+	if databaseMap.TickCadence.IsScheduled() && !databaseMap.IsFinished {
+		databaseMap.NextTickAtUnixSeconds = databaseMap.OrdersDeadline().Unix()
+	}
+}
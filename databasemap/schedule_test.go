@@ -0,0 +1,41 @@
+package databasemap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEachCadenceTicksAtItsNextScheduledTime(t *testing.T) {
+
+	after := time.Date(2021, 3, 14, 9, 26, 53, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2021, 3, 14, 10, 0, 0, 0, time.UTC), HourlyCadence.NextTickAfter(after))
+	assert.Equal(t, time.Date(2021, 3, 15, 0, 0, 0, 0, time.UTC), DailyCadence.NextTickAfter(after))
+	assert.Equal(t, time.Date(2021, 3, 15, majorUpdateFinishedHourUTC, 0, 0, 0, time.UTC), MajorUpdateCadence.NextTickAfter(after))
+
+	beforeTheMajorUpdate := time.Date(2021, 3, 14, 1, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2021, 3, 14, majorUpdateFinishedHourUTC, 0, 0, 0, time.UTC), MajorUpdateCadence.NextTickAfter(beforeTheMajorUpdate))
+}
+
+func TestAScheduledMapIsDueAtItsNextScheduledTime(t *testing.T) {
+
+	databaseMap := NewBlankDatabaseMap()
+	databaseMap.TickCadence = HourlyCadence
+	databaseMap.StartOrderPhase(time.Date(2021, 3, 14, 9, 26, 0, 0, time.UTC))
+
+	assert.False(t, databaseMap.IsDueForScheduledTick(time.Date(2021, 3, 14, 9, 59, 0, 0, time.UTC)))
+	assert.True(t, databaseMap.IsDueForScheduledTick(time.Date(2021, 3, 14, 10, 0, 0, 0, time.UTC)))
+}
+
+func TestAManualMapIsNeverDue(t *testing.T) {
+
+	databaseMap := NewBlankDatabaseMap()
+	databaseMap.StartOrderPhase(time.Date(2021, 3, 14, 9, 26, 0, 0, time.UTC))
+
+	assert.False(t, databaseMap.IsDueForScheduledTick(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)))
+
+	databaseMap.TickCadence = ManualCadence
+	assert.False(t, databaseMap.IsDueForScheduledTick(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)))
+}
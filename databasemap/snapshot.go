@@ -0,0 +1,65 @@
+package databasemap
+
+// DatabaseSnapshot is an immutable copy of a map's cells, wars and armies as they were when a year started, after the battles that opened it
+type DatabaseSnapshot struct {
+	MapID         string
+	Year          int
+	MapVersion    int // The version of the map it was taken from
+	Cells         map[string]DatabaseCell
+	Wars          map[string]DatabaseWar
+	Armies        map[string]DatabaseArmy
+	DroppedOrders []DatabaseDroppedOrder // The orders that couldn't be carried out when the year before resolved
+}
+
+func NewSnapshot(databaseMap DatabaseMap) DatabaseSnapshot {
+	snapshot := DatabaseSnapshot{
+		MapID:         databaseMap.ID,
+		Year:          databaseMap.Year,
+		MapVersion:    databaseMap.Version,
+		Cells:         make(map[string]DatabaseCell),
+		Wars:          make(map[string]DatabaseWar),
+		Armies:        make(map[string]DatabaseArmy),
+		DroppedOrders: append([]DatabaseDroppedOrder{}, databaseMap.DroppedOrders...),
+	}
+
+	for cellID, cell := range databaseMap.Cells {
+		cell.FormerResidents = append([]string{}, cell.FormerResidents...)
+		snapshot.Cells[cellID] = cell
+	}
+
+	for warID, war := range databaseMap.Wars {
+		war.Battles = append([]DatabaseBattle{}, war.Battles...)
+		war.AttackerAllies = append([]string{}, war.AttackerAllies...)
+		war.DefenderAllies = append([]string{}, war.DefenderAllies...)
+		snapshot.Wars[warID] = war
+	}
+
+	for armyID, army := range databaseMap.Armies {
+		snapshot.Armies[armyID] = army
+	}
+
+	return snapshot
+}
+
+func (snapshot DatabaseSnapshot) ToDatabaseMap(name string) DatabaseMap {
+	databaseMap := NewBlankDatabaseMap()
+	databaseMap.ID = snapshot.MapID
+	databaseMap.Name = name
+	databaseMap.Year = snapshot.Year
+
+	for cellID, cell := range snapshot.Cells {
+		databaseMap.Cells[cellID] = cell
+	}
+
+	for warID, war := range snapshot.Wars {
+		databaseMap.Wars[warID] = war
+	}
+
+	for armyID, army := range snapshot.Armies {
+		databaseMap.Armies[armyID] = army
+	}
+
+	databaseMap.DroppedOrders = append(databaseMap.DroppedOrders, snapshot.DroppedOrders...)
+
+	return databaseMap
+}
@@ -0,0 +1,44 @@
+package databasemap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangingAMapDoesntChangeItsSnapshot(t *testing.T) {
+
+	databaseMap := NewDatabaseMapWithTerritories([]string{"A"})
+	databaseMap.ID = "map1"
+	databaseMap.Year = 3
+	databaseMap.SetResident("A", "nation1")
+	databaseMap.PutWars([]DatabaseWar{NewWar("nation2", "nation1", "war", "A", 2)})
+
+	snapshot := NewSnapshot(databaseMap)
+
+	databaseMap.Year++
+	databaseMap.SetResident("A", "nation2")
+	war := databaseMap.Wars["war"]
+	war.Battles = append(war.Battles, DatabaseBattle{Year: 4})
+	databaseMap.PutWars([]DatabaseWar{war})
+
+	assert.Equal(t, "map1", snapshot.MapID)
+	assert.Equal(t, 3, snapshot.Year)
+	assert.Equal(t, "nation1", snapshot.Cells["A"].Resident)
+	assert.Empty(t, snapshot.Wars["war"].Battles)
+}
+
+func TestSnapshotConvertsBackToAMap(t *testing.T) {
+
+	databaseMap := NewDatabaseMapWithTerritories([]string{"A"})
+	databaseMap.ID = "map1"
+	databaseMap.Year = 3
+	databaseMap.SetResident("A", "nation1")
+
+	snapshotMap := NewSnapshot(databaseMap).ToDatabaseMap("map name")
+
+	assert.Equal(t, "map1", snapshotMap.ID)
+	assert.Equal(t, "map name", snapshotMap.Name)
+	assert.Equal(t, 3, snapshotMap.Year)
+	assert.Equal(t, "nation1", snapshotMap.Cells["A"].Resident)
+}
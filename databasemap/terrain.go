@@ -0,0 +1,87 @@
+package databasemap
+
+import (
+	"errors"
+)
+
+type Terrain string
+
+const (
+	Plains    Terrain = "plains"
+	Forest    Terrain = "forest"
+	Hills     Terrain = "hills"
+	Mountains Terrain = "mountains"
+	Coast     Terrain = "coast"
+	Marsh     Terrain = "marsh"
+)
+
+var Terrains = []Terrain{Plains, Forest, Hills, Mountains, Coast, Marsh}
+
+func (terrain Terrain) IsValid() bool {
+	for _, validTerrain := range Terrains {
+		if terrain == validTerrain {
+			return true
+		}
+	}
+	return false
+}
+
+func (terrain Terrain) DisplayName() string {
+	switch terrain {
+	case Forest:
+		return "Forest"
+	case Hills:
+		return "Hills"
+	case Mountains:
+		return "Mountains"
+	case Coast:
+		return "Coast"
+	case Marsh:
+		return "Marsh"
+	}
+	return "Plains"
+}
+
+const MaximumFortificationLevel = 3
+
+// Fortify raises the territory's fortifications by one level at most once a year and not while it is under attack
+func (databaseMap *DatabaseMap) Fortify(territoryID string, nationID string) error {
+	err := databaseMap.CheckNotFinished()
+	if err != nil {
+		return err
+	}
+
+	territory, doesTerritoryExist := databaseMap.Cells[territoryID]
+	if !doesTerritoryExist {
+		return errors.New("Territory does not exist")
+	}
+
+	if territory.Resident != nationID {
+		return errors.New("You must control a territory in order to fortify it")
+	}
+
+	if territory.FortificationLevel >= MaximumFortificationLevel {
+		return errors.New("The territory's fortifications can't be built any higher")
+	}
+
+	if territory.NextFortifyYear > databaseMap.Year {
+		return errors.New("The territory has already been fortified this year")
+	}
+
+	for _, war := range databaseMap.Wars {
+		if war.IsOngoing && war.TerritoryName == territoryID {
+			return errors.New("You can't build fortifications while the territory is under attack")
+		}
+	}
+
+	err = databaseMap.spend(nationID, FortifyCost)
+	if err != nil {
+		return err
+	}
+
+	territory.FortificationLevel++
+	territory.NextFortifyYear = databaseMap.Year + 1
+	databaseMap.Cells[territoryID] = territory
+
+	return nil
+}
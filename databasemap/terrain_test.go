@@ -0,0 +1,90 @@
+package databasemap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFortifyingRaisesTheLevelOnceAYear(t *testing.T) {
+
+	databaseMap := NewDatabaseMapWithTerritories([]string{"A"})
+	databaseMap.SetResident("A", "nation1")
+	databaseMap.Treasuries["nation1"] = 100
+
+	assert.NoError(t, databaseMap.Fortify("A", "nation1"))
+	assert.Equal(t, 1, databaseMap.Cells["A"].FortificationLevel)
+	assert.Error(t, databaseMap.Fortify("A", "nation1"))
+
+	databaseMap.Year++
+	assert.NoError(t, databaseMap.Fortify("A", "nation1"))
+	assert.Equal(t, 2, databaseMap.Cells["A"].FortificationLevel)
+}
+
+func TestFortificationsHaveAMaximumLevel(t *testing.T) {
+
+	databaseMap := NewDatabaseMapWithTerritories([]string{"A"})
+	databaseMap.SetResident("A", "nation1")
+	databaseMap.Treasuries["nation1"] = 100
+
+	for level := 0; level < MaximumFortificationLevel; level++ {
+		databaseMap.Year++
+		assert.NoError(t, databaseMap.Fortify("A", "nation1"))
+	}
+
+	databaseMap.Year++
+	assert.Error(t, databaseMap.Fortify("A", "nation1"))
+	assert.Equal(t, MaximumFortificationLevel, databaseMap.Cells["A"].FortificationLevel)
+}
+
+func TestOnlyTheResidentCanFortifyAndNotDuringAWar(t *testing.T) {
+
+	databaseMap := NewDatabaseMapWithTerritories([]string{"A"})
+	databaseMap.SetResident("A", "nation1")
+	databaseMap.Treasuries["nation1"] = 100
+
+	assert.Error(t, databaseMap.Fortify("A", "nation2"))
+	assert.Error(t, databaseMap.Fortify("B", "nation1"))
+
+	databaseMap.PutWars([]DatabaseWar{NewWar("nation2", "nation1", "warForA", "A", 0)})
+	assert.Error(t, databaseMap.Fortify("A", "nation1"))
+}
+
+func TestConquestDamagesFortifications(t *testing.T) {
+
+	databaseMap := NewDatabaseMapWithTerritories([]string{"A"})
+	databaseMap.SetResident("A", "nation1")
+	databaseMap.Treasuries["nation1"] = 100
+	databaseMap.Fortify("A", "nation1")
+	databaseMap.Year++
+	databaseMap.Fortify("A", "nation1")
+
+	databaseMap.SetResident("A", "nation2")
+	assert.Equal(t, 1, databaseMap.Cells["A"].FortificationLevel)
+}
+
+func TestATerritoryCantBeFortifiedAgainInTheYearConquestKnockedItDown(t *testing.T) {
+
+	databaseMap := NewDatabaseMapWithTerritories([]string{"A"})
+	databaseMap.SetResident("A", "nation1")
+	databaseMap.Treasuries["nation1"] = 100
+	assert.NoError(t, databaseMap.Fortify("A", "nation1"))
+
+	databaseMap.SetResident("A", "nation2")
+	databaseMap.SetResident("A", "nation1")
+	assert.Equal(t, 0, databaseMap.Cells["A"].FortificationLevel)
+	assert.Error(t, databaseMap.Fortify("A", "nation1"))
+}
+
+func TestFortifyingCostsGold(t *testing.T) {
+
+	databaseMap := NewDatabaseMapWithTerritories([]string{"A"})
+	databaseMap.SetResident("A", "nation1")
+	databaseMap.Treasuries["nation1"] = FortifyCost - 1
+
+	assert.Error(t, databaseMap.Fortify("A", "nation1"))
+
+	databaseMap.Treasuries["nation1"] = FortifyCost
+	assert.NoError(t, databaseMap.Fortify("A", "nation1"))
+	assert.Equal(t, 0, databaseMap.GetTreasury("nation1"))
+}
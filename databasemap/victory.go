@@ -0,0 +1,203 @@
+package databasemap
+
+import (
+	"errors"
+	"sort"
+)
+
+// VictoryCondition is a way for the game on a map to end
+type VictoryCondition string
+
+const (
+	Domination     VictoryCondition = "domination"
+	TerritoryShare VictoryCondition = "territory_share"
+	YearLimit      VictoryCondition = "year_limit"
+	AIDefeated     VictoryCondition = "ai_defeated"
+)
+
+var VictoryConditions = []VictoryCondition{Domination, TerritoryShare, YearLimit, AIDefeated}
+
+const DefaultVictoryTerritoryPercent = 75
+const MinimumVictoryTerritoryPercent = 51 // More than half so only one nation can reach it
+const DefaultVictoryYearLimit = 50
+
+func (condition VictoryCondition) IsValid() bool {
+	for _, validCondition := range VictoryConditions {
+		if condition == validCondition {
+			return true
+		}
+	}
+	return false
+}
+
+func (condition VictoryCondition) DisplayName() string {
+	switch condition {
+	case TerritoryShare:
+		return "Territory Share"
+	case YearLimit:
+		return "Year Limit"
+	case AIDefeated:
+		return "AI Defeated"
+	}
+	return "Domination"
+}
+
+func (databaseMap DatabaseMap) HasVictoryCondition(condition VictoryCondition) bool {
+	for _, victoryCondition := range databaseMap.VictoryConditions {
+		if victoryCondition == condition {
+			return true
+		}
+	}
+	return false
+}
+
+var MapFinishedError = errors.New("The game on this map is over")
+
+func (databaseMap DatabaseMap) GetVictoryTerritoryPercent() int {
+	if databaseMap.VictoryTerritoryPercent == 0 {
+		return DefaultVictoryTerritoryPercent
+	}
+	return databaseMap.VictoryTerritoryPercent
+}
+
+func (databaseMap DatabaseMap) GetVictoryYearLimit() int {
+	if databaseMap.VictoryYearLimit == 0 {
+		return DefaultVictoryYearLimit
+	}
+	return databaseMap.VictoryYearLimit
+}
+
+func (databaseMap DatabaseMap) CheckNotFinished() error {
+	if databaseMap.IsFinished {
+		return MapFinishedError
+	}
+	return nil
+}
+
+func (databaseMap DatabaseMap) countTerritories() map[string]int {
+	territoryCounts := make(map[string]int)
+	for _, cell := range databaseMap.Cells {
+		if len(cell.Resident) != 0 {
+			territoryCounts[cell.Resident]++
+		}
+	}
+	return territoryCounts
+}
+
+// CheckVictory finishes the game if any of the map's victory conditions has been met
+func (databaseMap *DatabaseMap) CheckVictory(aiNationIDs []string) bool {
+	if databaseMap.IsFinished {
+		return true
+	}
+
+	territoryCounts := databaseMap.countTerritories()
+	players := databaseMap.GetPlayers()
+
+	for _, condition := range databaseMap.VictoryConditions {
+		winners := []string{}
+
+		switch condition {
+		case Domination:
+			if len(players) == 1 && territoryCounts[players[0]] == len(databaseMap.Cells) {
+				winners = players
+			}
+		case TerritoryShare:
+			for _, player := range players {
+				if territoryCounts[player]*100 >= databaseMap.GetVictoryTerritoryPercent()*len(databaseMap.Cells) {
+					winners = append(winners, player)
+				}
+			}
+		case YearLimit:
+			if databaseMap.Year >= databaseMap.GetVictoryYearLimit() {
+				mostTerritories := 0
+				for _, player := range players {
+					if territoryCounts[player] > mostTerritories {
+						mostTerritories = territoryCounts[player]
+						winners = []string{}
+					}
+					if territoryCounts[player] == mostTerritories {
+						winners = append(winners, player)
+					}
+				}
+			}
+		case AIDefeated:
+			wasAIPresent := false
+			isAIPresent := false
+			for _, aiNationID := range aiNationIDs {
+				wasAIPresent = wasAIPresent || databaseMap.HasParticipant(aiNationID)
+				isAIPresent = isAIPresent || territoryCounts[aiNationID] != 0
+			}
+			if wasAIPresent && !isAIPresent {
+				winners = players
+			}
+		}
+
+		if len(winners) != 0 {
+			databaseMap.IsFinished = true
+			databaseMap.FinishedYear = databaseMap.Year
+			databaseMap.Winners = winners
+			databaseMap.VictoryReason = condition
+			databaseMap.endOngoingWars()
+			return true
+		}
+	}
+
+	return false
+}
+
+// endOngoingWars leaves nothing being fought once the game is over
+func (databaseMap *DatabaseMap) endOngoingWars() {
+	for warID, war := range databaseMap.Wars {
+		if !war.IsOngoing {
+			continue
+		}
+
+		war.IsOngoing = false
+		war.Outcome = OutcomeWhitePeace
+		databaseMap.Wars[warID] = war
+		databaseMap.ClearPeaceOffer(warID)
+	}
+}
+
+type Standing struct {
+	NationID    string
+	Territories int
+	WarsWon     int
+	Gold        int
+	Regiments   int
+}
+
+// GetStandings ranks every nation that has played on the map by territories held, then wars won and then gold
+func (databaseMap DatabaseMap) GetStandings() []Standing {
+	territoryCounts := databaseMap.countTerritories()
+
+	standings := []Standing{}
+	for _, participant := range databaseMap.Participants {
+		standing := Standing{
+			NationID:    participant,
+			Territories: territoryCounts[participant],
+			Gold:        databaseMap.GetTreasury(participant),
+			Regiments:   databaseMap.GetRegiments(participant),
+		}
+
+		for _, war := range databaseMap.Wars {
+			if war.Victor == participant {
+				standing.WarsWon++
+			}
+		}
+
+		standings = append(standings, standing)
+	}
+
+	sort.SliceStable(standings, func(i, j int) bool {
+		if standings[i].Territories != standings[j].Territories {
+			return standings[i].Territories > standings[j].Territories
+		}
+		if standings[i].WarsWon != standings[j].WarsWon {
+			return standings[i].WarsWon > standings[j].WarsWon
+		}
+		return standings[i].Gold > standings[j].Gold
+	})
+
+	return standings
+}
@@ -0,0 +1,128 @@
+package databasemap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func makeVictoryTestMap(victoryConditions ...VictoryCondition) DatabaseMap {
+	databaseMap := NewDatabaseMapWithTerritories([]string{"A", "B", "C", "D"})
+	databaseMap.SetResident("A", "nation1")
+	databaseMap.SetResident("B", "nation1")
+	databaseMap.SetResident("C", "nation2")
+	databaseMap.SetResident("D", "empire")
+	databaseMap.VictoryConditions = victoryConditions
+	return databaseMap
+}
+
+func TestAMapWithoutVictoryConditionsNeverFinishes(t *testing.T) {
+
+	databaseMap := makeVictoryTestMap()
+	databaseMap.SetResident("C", "nation1")
+	databaseMap.SetResident("D", "nation1")
+
+	assert.False(t, databaseMap.CheckVictory([]string{"empire"}))
+	assert.False(t, databaseMap.IsFinished)
+}
+
+func TestHoldingEveryTerritoryWinsByDomination(t *testing.T) {
+
+	databaseMap := makeVictoryTestMap(Domination)
+	databaseMap.SetResident("C", "nation1")
+	assert.False(t, databaseMap.CheckVictory([]string{}))
+
+	databaseMap.Year = 12
+	databaseMap.SetResident("D", "nation1")
+	assert.True(t, databaseMap.CheckVictory([]string{}))
+
+	assert.True(t, databaseMap.IsFinished)
+	assert.Equal(t, 12, databaseMap.FinishedYear)
+	assert.Equal(t, []string{"nation1"}, databaseMap.Winners)
+	assert.Equal(t, Domination, databaseMap.VictoryReason)
+	assert.Equal(t, MapFinishedError, databaseMap.SubmitOrders("nation1"))
+}
+
+func TestFinishingTheMapEndsTheWarsStillBeingFought(t *testing.T) {
+
+	databaseMap := makeVictoryTestMap(TerritoryShare)
+	databaseMap.VictoryTerritoryPercent = 75
+	databaseMap.Wars["war1"] = NewWar("nation2", "empire", "war1", "D", 0)
+	assert.NoError(t, databaseMap.OfferPeace("war1", "nation2", WhitePeaceTerms, 0))
+
+	databaseMap.SetResident("C", "nation1")
+	assert.True(t, databaseMap.CheckVictory([]string{}))
+
+	assert.False(t, databaseMap.Wars["war1"].IsOngoing)
+	assert.Equal(t, OutcomeWhitePeace, databaseMap.Wars["war1"].Outcome)
+	assert.Empty(t, databaseMap.PeaceOffers)
+}
+
+func TestNothingCanBeDoneOnAFinishedMap(t *testing.T) {
+
+	databaseMap := makeVictoryTestMap()
+	databaseMap.Wars["war1"] = NewWar("nation2", "empire", "war1", "D", 0)
+	databaseMap.Treasuries["nation1"] = 100
+	databaseMap.addRegiment("nation1", "A")
+	databaseMap.IsFinished = true
+
+	assert.Equal(t, MapFinishedError, databaseMap.Recruit("nation1", "A"))
+	assert.Equal(t, MapFinishedError, databaseMap.Fortify("A", "nation1"))
+	assert.Equal(t, MapFinishedError, databaseMap.FundWar("war1", "nation1", 10))
+	assert.Equal(t, MapFinishedError, databaseMap.OrderArmyMove("1", "nation1", "B", func(string, string) bool { return true }))
+	assert.Equal(t, MapFinishedError, databaseMap.OfferPeace("war1", "nation2", WhitePeaceTerms, 0))
+	assert.Equal(t, MapFinishedError, databaseMap.ProposeAlliance("nation1", "nation2"))
+	assert.Equal(t, MapFinishedError, databaseMap.BreakAlliance("nation1", "nation2"))
+}
+
+func TestHoldingEnoughOfTheTerritoriesWinsByTerritoryShare(t *testing.T) {
+
+	databaseMap := makeVictoryTestMap(TerritoryShare)
+	databaseMap.VictoryTerritoryPercent = 75
+	assert.False(t, databaseMap.CheckVictory([]string{}))
+
+	databaseMap.SetResident("C", "nation1")
+	assert.True(t, databaseMap.CheckVictory([]string{}))
+	assert.Equal(t, []string{"nation1"}, databaseMap.Winners)
+}
+
+func TestTheNationsWithTheMostTerritoriesWinAtTheYearLimit(t *testing.T) {
+
+	databaseMap := makeVictoryTestMap(YearLimit)
+	databaseMap.VictoryYearLimit = 10
+	databaseMap.SetResident("D", "nation2")
+
+	databaseMap.Year = 9
+	assert.False(t, databaseMap.CheckVictory([]string{}))
+
+	databaseMap.Year = 10
+	assert.True(t, databaseMap.CheckVictory([]string{}))
+	assert.Equal(t, []string{"nation1", "nation2"}, databaseMap.Winners)
+}
+
+func TestEveryRemainingNationWinsWhenTheAIIsDefeated(t *testing.T) {
+
+	databaseMap := makeVictoryTestMap(AIDefeated)
+	assert.False(t, databaseMap.CheckVictory([]string{"empire"}))
+
+	databaseMap.SetResident("D", "nation2")
+	assert.False(t, databaseMap.CheckVictory([]string{"otherEmpire"}))
+	assert.True(t, databaseMap.CheckVictory([]string{"empire"}))
+	assert.Equal(t, []string{"nation1", "nation2"}, databaseMap.Winners)
+}
+
+func TestStandingsRankByTerritoriesThenWarsWonThenGold(t *testing.T) {
+
+	databaseMap := makeVictoryTestMap()
+	databaseMap.Treasuries["nation2"] = 5
+	databaseMap.Treasuries["empire"] = 10
+	wonWar := NewWar("nation2", "nation3", "warForE", "E", 0)
+	wonWar.Victor = "nation2"
+	databaseMap.PutWars([]DatabaseWar{wonWar})
+
+	standings := databaseMap.GetStandings()
+	assert.Len(t, standings, 3)
+	assert.Equal(t, Standing{NationID: "nation1", Territories: 2}, standings[0])
+	assert.Equal(t, Standing{NationID: "nation2", Territories: 1, WarsWon: 1, Gold: 5}, standings[1])
+	assert.Equal(t, Standing{NationID: "empire", Territories: 1, Gold: 10}, standings[2])
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"log"
 	"os"
+	"strconv"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -16,6 +17,8 @@ import (
 
 var MapDoesntExistError = errors.New("Map doesn't exist")
 var SessionDoesntExistError = errors.New("Session doesn't exist")
+var SnapshotDoesntExistError = errors.New("Snapshot doesn't exist")
+var SnapshotAlreadyExistsError = errors.New("Snapshot already exists")
 
 var dynamodbClient *dynamodb.Client = nil
 var databaseContext = context.TODO()
@@ -70,39 +73,339 @@ func GetMap(ID string) (databasemap.DatabaseMap, error) {
 	return gotItem, nil
 }
 
+// getStoredParticipants only reads the stored map's version and participants, which is all PutMap needs to find stale index rows
+func getStoredParticipants(ID string) (databasemap.DatabaseMap, error) {
+	log.Println("DynamoDB: Get on map table")
+	getItemOutput, err := dynamodbClient.GetItem(databaseContext, &dynamodb.GetItemInput{
+		TableName: aws.String(mapTableName()),
+		Key: map[string]types.AttributeValue{
+			"ID": &types.AttributeValueMemberS{
+				Value: ID,
+			},
+		},
+		ProjectionExpression: aws.String("Version, Participants"),
+		ConsistentRead:       aws.Bool(true),
+	})
+
+	if err != nil {
+		return databasemap.NewBlankDatabaseMap(), err
+	}
+
+	if len(getItemOutput.Item) == 0 {
+		return databasemap.NewBlankDatabaseMap(), databasemap.VersionConflictError{MapID: ID}
+	}
+
+	storedMap := databasemap.NewBlankDatabaseMap()
+	err = attributevalue.UnmarshalMap(getItemOutput.Item, &storedMap)
+	if err != nil {
+		return databasemap.NewBlankDatabaseMap(), err
+	}
+
+	return storedMap, nil
+}
+
+// PutMap only succeeds if the stored map still has the version that was read and saves it with the next version
 func PutMap(item databasemap.DatabaseMap) error {
 
+	expectedVersion := item.Version
+	item.Version++
+	item.ScheduleNextTick()
+
 	itemToPutMap, err := attributevalue.MarshalMap(item)
 	if err != nil {
 		return err
 	}
 
-	log.Println("DynamoDB: Put on map table")
-	_, err = dynamodbClient.PutItem(databaseContext, &dynamodb.PutItemInput{
-		TableName: aws.String(mapTableName()),
-		Item:      itemToPutMap,
+	// maps the scheduler has to end share one partition of the schedule index, sorted by when they're due
+	if item.NextTickAtUnixSeconds != 0 {
+		itemToPutMap[scheduleAttributeName] = &types.AttributeValueMemberS{
+			Value: scheduledMapsPartition,
+		}
+	}
+
+	conditionExpression := "Version = :expectedVersion"
+	expressionAttributeValues := map[string]types.AttributeValue{
+		":expectedVersion": &types.AttributeValueMemberN{
+			Value: strconv.Itoa(expectedVersion),
+		},
+	}
+	if expectedVersion == 0 {
+		conditionExpression = "attribute_not_exists(Version)"
+		expressionAttributeValues = nil
+	}
+
+	staleParticipants := []string{}
+	if expectedVersion != 0 {
+		storedMap, err := getStoredParticipants(item.ID)
+		if err != nil {
+			return err
+		}
+
+		if storedMap.Version != expectedVersion {
+			return databasemap.VersionConflictError{MapID: item.ID}
+		}
+
+		staleParticipants = storedMap.ParticipantsMissingFrom(item)
+	}
+
+	// the index rows go in the same transaction so a map is never saved without them or with rows it no longer has
+	transactItems := []types.TransactWriteItem{{
+		Put: &types.Put{
+			TableName:                 aws.String(mapTableName()),
+			Item:                      itemToPutMap,
+			ConditionExpression:       aws.String(conditionExpression),
+			ExpressionAttributeValues: expressionAttributeValues,
+		},
+	}}
+
+	for _, participant := range item.Participants {
+		nationMapToPutMap, err := attributevalue.MarshalMap(DatabaseNationMap{NationName: participant, MapID: item.ID})
+		if err != nil {
+			return err
+		}
+
+		transactItems = append(transactItems, types.TransactWriteItem{
+			Put: &types.Put{
+				TableName: aws.String(nationMapTableName()),
+				Item:      nationMapToPutMap,
+			},
+		})
+	}
+
+	for _, participant := range staleParticipants {
+		nationMapKey, err := attributevalue.MarshalMap(DatabaseNationMap{NationName: participant, MapID: item.ID})
+		if err != nil {
+			return err
+		}
+
+		transactItems = append(transactItems, types.TransactWriteItem{
+			Delete: &types.Delete{
+				TableName: aws.String(nationMapTableName()),
+				Key:       nationMapKey,
+			},
+		})
+	}
+
+	log.Println("DynamoDB: Transact write on map and nation map tables")
+	_, err = dynamodbClient.TransactWriteItems(databaseContext, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
 	})
+
+	var transactionCanceled *types.TransactionCanceledException
+	if errors.As(err, &transactionCanceled) && len(transactionCanceled.CancellationReasons) != 0 && aws.ToString(transactionCanceled.CancellationReasons[0].Code) == "ConditionalCheckFailed" {
+		return databasemap.VersionConflictError{MapID: item.ID}
+	}
+
 	return err
 }
 
-func GetAllMaps() ([]databasemap.DatabaseMap, error) {
+func nationMapTableName() string {
+	return getTableName("NATION_MAP_TABLE_NAME", "nsimperialism-nation-map")
+}
+
+type DatabaseNationMap struct {
+	NationName string
+	MapID      string
+}
 
-	log.Println("DynamoDB: Scan on all of map table")
-	scanOutput, err := dynamodbClient.Scan(databaseContext, &dynamodb.ScanInput{
+// QueryMapIDsForNation reads one page of the nation's map IDs after the given one and returns where the next page starts, or empty after the last page
+func QueryMapIDsForNation(nationName string, exclusiveStartMapID string, limit int) ([]string, string, error) {
+
+	queryInput := &dynamodb.QueryInput{
+		TableName:              aws.String(nationMapTableName()),
+		KeyConditionExpression: aws.String("NationName = :nationName"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":nationName": &types.AttributeValueMemberS{
+				Value: nationName,
+			},
+		},
+		Limit: aws.Int32(int32(limit)),
+	}
+	if len(exclusiveStartMapID) != 0 {
+		queryInput.ExclusiveStartKey = map[string]types.AttributeValue{
+			"NationName": &types.AttributeValueMemberS{
+				Value: nationName,
+			},
+			"MapID": &types.AttributeValueMemberS{
+				Value: exclusiveStartMapID,
+			},
+		}
+	}
+
+	log.Println("DynamoDB: Query on nation map table")
+	queryOutput, err := dynamodbClient.Query(databaseContext, queryInput)
+	if err != nil {
+		return nil, "", err
+	}
+
+	nationMaps := []DatabaseNationMap{}
+	err = attributevalue.UnmarshalListOfMaps(queryOutput.Items, &nationMaps)
+	if err != nil {
+		return nil, "", err
+	}
+
+	mapIDs := []string{}
+	for _, nationMap := range nationMaps {
+		mapIDs = append(mapIDs, nationMap.MapID)
+	}
+
+	lastEvaluatedMapID := ""
+	lastEvaluatedMapIDAttribute, isMapIDString := queryOutput.LastEvaluatedKey["MapID"].(*types.AttributeValueMemberS)
+	if isMapIDString {
+		lastEvaluatedMapID = lastEvaluatedMapIDAttribute.Value
+	}
+
+	return mapIDs, lastEvaluatedMapID, nil
+}
+
+// ScanMaps reads one page of maps after the given ID and returns where the next page starts, or empty after the last page
+func ScanMaps(exclusiveStartID string, limit int) ([]databasemap.DatabaseMap, string, error) {
+
+	scanInput := &dynamodb.ScanInput{
 		TableName:      aws.String(mapTableName()),
 		ConsistentRead: aws.Bool(true),
-	})
+		Limit:          aws.Int32(int32(limit)),
+	}
+	if len(exclusiveStartID) != 0 {
+		scanInput.ExclusiveStartKey = map[string]types.AttributeValue{
+			"ID": &types.AttributeValueMemberS{
+				Value: exclusiveStartID,
+			},
+		}
+	}
+
+	log.Println("DynamoDB: Scan on a page of map table")
+	scanOutput, err := dynamodbClient.Scan(databaseContext, scanInput)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	maps := []databasemap.DatabaseMap{}
 	err = attributevalue.UnmarshalListOfMaps(scanOutput.Items, &maps)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+
+	lastEvaluatedID := ""
+	lastEvaluatedIDAttribute, isIDString := scanOutput.LastEvaluatedKey["ID"].(*types.AttributeValueMemberS)
+	if isIDString {
+		lastEvaluatedID = lastEvaluatedIDAttribute.Value
+	}
+
+	return maps, lastEvaluatedID, nil
+}
+
+const scheduleAttributeName = "Schedule"
+const scheduledMapsPartition = "scheduled"
+
+func mapScheduleIndexName() string {
+	return getTableName("MAP_SCHEDULE_INDEX_NAME", "Schedule-NextTickAtUnixSeconds-index")
+}
+
+// QueryMapIDsDueToTick reads the map schedule index up to the given time, so maps that aren't due yet, manual maps and finished maps are never read
+func QueryMapIDsDueToTick(nowUnixSeconds int64) ([]string, error) {
+
+	queryInput := &dynamodb.QueryInput{
+		TableName:              aws.String(mapTableName()),
+		IndexName:              aws.String(mapScheduleIndexName()),
+		KeyConditionExpression: aws.String("#schedule = :scheduled AND NextTickAtUnixSeconds <= :now"),
+		ProjectionExpression:   aws.String("ID"),
+		ExpressionAttributeNames: map[string]string{
+			"#schedule": scheduleAttributeName,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":scheduled": &types.AttributeValueMemberS{
+				Value: scheduledMapsPartition,
+			},
+			":now": &types.AttributeValueMemberN{
+				Value: strconv.FormatInt(nowUnixSeconds, 10),
+			},
+		},
+	}
+
+	mapIDs := []string{}
+	for {
+		log.Println("DynamoDB: Query on a page of map schedule index")
+		queryOutput, err := dynamodbClient.Query(databaseContext, queryInput)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range queryOutput.Items {
+			mapIDAttribute, isMapIDString := item["ID"].(*types.AttributeValueMemberS)
+			if isMapIDString {
+				mapIDs = append(mapIDs, mapIDAttribute.Value)
+			}
+		}
+
+		if len(queryOutput.LastEvaluatedKey) == 0 {
+			return mapIDs, nil
+		}
+
+		queryInput.ExclusiveStartKey = queryOutput.LastEvaluatedKey
+	}
+}
+
+func mapSnapshotTableName() string {
+	return getTableName("MAP_SNAPSHOT_TABLE_NAME", "nsimperialism-map-snapshot")
+}
+
+func GetSnapshot(mapID string, year int) (databasemap.DatabaseSnapshot, error) {
+	log.Println("DynamoDB: Get on map snapshot table")
+	getItemOutput, err := dynamodbClient.GetItem(databaseContext, &dynamodb.GetItemInput{
+		TableName: aws.String(mapSnapshotTableName()),
+		Key: map[string]types.AttributeValue{
+			"MapID": &types.AttributeValueMemberS{
+				Value: mapID,
+			},
+			"Year": &types.AttributeValueMemberN{
+				Value: strconv.Itoa(year),
+			},
+		},
+	})
+
+	if err != nil {
+		return databasemap.DatabaseSnapshot{}, err
+	}
+
+	if len(getItemOutput.Item) == 0 {
+		return databasemap.DatabaseSnapshot{}, SnapshotDoesntExistError
+	}
+
+	gotItem := databasemap.DatabaseSnapshot{}
+	err = attributevalue.UnmarshalMap(getItemOutput.Item, &gotItem)
+	if err != nil {
+		return databasemap.DatabaseSnapshot{}, err
 	}
 
-	return maps, nil
+	return gotItem, nil
+}
+
+// PutSnapshot only replaces a snapshot taken from an older version of the map, which is left behind when saving the map conflicted
+func PutSnapshot(item databasemap.DatabaseSnapshot) error {
+	itemToPutMap, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		return err
+	}
+
+	log.Println("DynamoDB: Put on map snapshot table")
+	_, err = dynamodbClient.PutItem(databaseContext, &dynamodb.PutItemInput{
+		TableName:           aws.String(mapSnapshotTableName()),
+		Item:                itemToPutMap,
+		ConditionExpression: aws.String("attribute_not_exists(MapID) OR attribute_not_exists(MapVersion) OR MapVersion < :mapVersion"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":mapVersion": &types.AttributeValueMemberN{
+				Value: strconv.Itoa(item.MapVersion),
+			},
+		},
+	})
+
+	var conditionalCheckFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionalCheckFailed) {
+		return SnapshotAlreadyExistsError
+	}
+
+	return err
 }
 
 func sessionTableName() string {
@@ -12,4 +12,5 @@ require (
 	github.com/gorilla/mux v1.8.0
 	github.com/joho/godotenv v1.3.0
 	github.com/stretchr/testify v1.6.1
+	go.etcd.io/bbolt v1.3.5
 )
@@ -14,9 +14,37 @@ import (
 	"time"
 )
 
+const CENSUSSCALECIVILRIGHTS int = 0
+const CENSUSSCALEECONOMY int = 1
+const CENSUSSCALEPOLITICALFREEDOM int = 2
+const CENSUSSCALEPOPULATION int = 3
 const CENSUSSCALEDEFENSEFORCES int = 46
 const CENSUSSCALESCIENTIFICADVANCEMENT int = 70
 
+// CombatCensusScales are fetched with every nation so any combat model can be applied without another request
+var CombatCensusScales = []int{CENSUSSCALEDEFENSEFORCES, CENSUSSCALESCIENTIFICADVANCEMENT, CENSUSSCALEECONOMY, CENSUSSCALEPOPULATION}
+
+// UnrestCensusScales decide how restless the territories a nation occupies are
+var UnrestCensusScales = []int{CENSUSSCALECIVILRIGHTS, CENSUSSCALEPOLITICALFREEDOM}
+
+func CensusScaleName(scale int) string {
+	switch scale {
+	case CENSUSSCALECIVILRIGHTS:
+		return "Civil Rights"
+	case CENSUSSCALEPOLITICALFREEDOM:
+		return "Political Freedom"
+	case CENSUSSCALEECONOMY:
+		return "Economy"
+	case CENSUSSCALEPOPULATION:
+		return "Population"
+	case CENSUSSCALEDEFENSEFORCES:
+		return "Defense Forces"
+	case CENSUSSCALESCIENTIFICADVANCEMENT:
+		return "Scientific Advancement"
+	}
+	return fmt.Sprintf("Census Scale %d", scale)
+}
+
 var rateLimitDuration, _ = time.ParseDuration("30s")
 var limiter = NewRateLimiter(40, rateLimitDuration) // API Docs say 50 requests in 30 seconds so I'm being a little conservative so we don't get locked out https://www.nationstates.net/pages/api.html#ratelimits
 var cacheExpirationDuration, _ = time.ParseDuration("12h")
@@ -108,7 +136,12 @@ func GetNationData(nationName string) (*Nation, error) {
 		return nil, errors.New("Hit internal nationstates API rate limit")
 	}
 
-	url := fmt.Sprintf("https://www.nationstates.net/cgi-bin/api.cgi?nation=%s;q=census+fullname+flag+demonym;scale=46;mode=prank", url.QueryEscape(nationName))
+	scales := []string{}
+	for _, scale := range append(append([]int{}, CombatCensusScales...), UnrestCensusScales...) {
+		scales = append(scales, strconv.Itoa(scale))
+	}
+
+	url := fmt.Sprintf("https://www.nationstates.net/cgi-bin/api.cgi?nation=%s;q=census+fullname+flag+demonym;scale=%s;mode=prank", url.QueryEscape(nationName), strings.Join(scales, "+"))
 	log.Println("Pulling down nation data for", nationName)
 	request, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -0,0 +1,355 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/brickman1444/NSImperialism/databasemap"
+	"github.com/brickman1444/NSImperialism/dynamodbwrapper"
+	bolt "go.etcd.io/bbolt"
+)
+
+var mapBucketName = []byte("maps")
+var sessionBucketName = []byte("sessions")
+var nationMapBucketName = []byte("nation_maps")
+var snapshotBucketName = []byte("map_snapshots")
+var scheduleBucketName = []byte("map_schedule")
+
+func nationMapKey(nationID string, mapID string) []byte {
+	return []byte(nationID + "\x00" + mapID)
+}
+
+// scheduleKey sorts by when the map is due so the scheduler can stop at the first map that isn't
+func scheduleKey(nextTickAtUnixSeconds int64, mapID string) []byte {
+	key := make([]byte, 8, 8+len(mapID))
+	binary.BigEndian.PutUint64(key, uint64(nextTickAtUnixSeconds))
+	return append(key, mapID...)
+}
+
+// createScheduleBucket fills in the schedule for maps saved before it existed
+func createScheduleBucket(transaction *bolt.Tx) error {
+	if transaction.Bucket(scheduleBucketName) != nil {
+		return nil
+	}
+
+	scheduleBucket, err := transaction.CreateBucket(scheduleBucketName)
+	if err != nil {
+		return err
+	}
+
+	return transaction.Bucket(mapBucketName).ForEach(func(mapID []byte, mapBytes []byte) error {
+		databaseMap, err := unmarshalMap(mapBytes)
+		if err != nil {
+			return err
+		}
+
+		databaseMap.Normalize()
+		databaseMap.ScheduleNextTick()
+		if databaseMap.NextTickAtUnixSeconds == 0 {
+			return nil
+		}
+
+		return scheduleBucket.Put(scheduleKey(databaseMap.NextTickAtUnixSeconds, string(mapID)), []byte{})
+	})
+}
+
+// BoltRepository stores everything in a single local file so the game can be self-hosted without DynamoDB
+type BoltRepository struct {
+	database *bolt.DB
+}
+
+func boltFilePath() string {
+	environmentVariableValue, doesEnvironmentVariableExist := os.LookupEnv("STORAGE_FILE")
+	if doesEnvironmentVariableExist {
+		return environmentVariableValue
+	}
+
+	return "nsimperialism.db"
+}
+
+func NewBoltRepository(filePath string) (BoltRepository, error) {
+	database, err := bolt.Open(filePath, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return BoltRepository{}, err
+	}
+
+	err = database.Update(func(transaction *bolt.Tx) error {
+		for _, bucketName := range [][]byte{mapBucketName, sessionBucketName, nationMapBucketName, snapshotBucketName} {
+			_, err := transaction.CreateBucketIfNotExists(bucketName)
+			if err != nil {
+				return err
+			}
+		}
+		return createScheduleBucket(transaction)
+	})
+	if err != nil {
+		database.Close()
+		return BoltRepository{}, err
+	}
+
+	return BoltRepository{database: database}, nil
+}
+
+func (repository BoltRepository) Close() error {
+	return repository.database.Close()
+}
+
+func (repository BoltRepository) GetMap(mapID string) (databasemap.DatabaseMap, error) {
+	databaseMap := databasemap.NewBlankDatabaseMap()
+	err := repository.database.View(func(transaction *bolt.Tx) error {
+		mapBytes := transaction.Bucket(mapBucketName).Get([]byte(mapID))
+		if mapBytes == nil {
+			return MapDoesntExistError
+		}
+
+		var err error
+		databaseMap, err = unmarshalMap(mapBytes)
+		return err
+	})
+	if err != nil {
+		return databasemap.NewBlankDatabaseMap(), err
+	}
+
+	return databaseMap, nil
+}
+
+func (repository BoltRepository) PutMap(databaseMap databasemap.DatabaseMap) error {
+	return repository.database.Update(func(transaction *bolt.Tx) error {
+		bucket := transaction.Bucket(mapBucketName)
+
+		storedVersion := 0
+		storedNextTickAtUnixSeconds := int64(0)
+		staleParticipants := []string{}
+		storedMapBytes := bucket.Get([]byte(databaseMap.ID))
+		if storedMapBytes != nil {
+			storedMap, err := unmarshalMap(storedMapBytes)
+			if err != nil {
+				return err
+			}
+			storedVersion = storedMap.Version
+			staleParticipants = storedMap.ParticipantsMissingFrom(databaseMap)
+
+			storedMap.Normalize()
+			storedMap.ScheduleNextTick()
+			storedNextTickAtUnixSeconds = storedMap.NextTickAtUnixSeconds
+		}
+
+		if storedVersion != databaseMap.Version {
+			return databasemap.VersionConflictError{MapID: databaseMap.ID}
+		}
+
+		databaseMap.Version++
+		databaseMap.ScheduleNextTick()
+
+		mapBytes, err := json.Marshal(databaseMap)
+		if err != nil {
+			return err
+		}
+
+		err = bucket.Put([]byte(databaseMap.ID), mapBytes)
+		if err != nil {
+			return err
+		}
+
+		for _, participant := range staleParticipants {
+			err = transaction.Bucket(nationMapBucketName).Delete(nationMapKey(participant, databaseMap.ID))
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, participant := range databaseMap.Participants {
+			err = transaction.Bucket(nationMapBucketName).Put(nationMapKey(participant, databaseMap.ID), []byte{})
+			if err != nil {
+				return err
+			}
+		}
+
+		scheduleBucket := transaction.Bucket(scheduleBucketName)
+		if storedNextTickAtUnixSeconds != 0 {
+			err = scheduleBucket.Delete(scheduleKey(storedNextTickAtUnixSeconds, databaseMap.ID))
+			if err != nil {
+				return err
+			}
+		}
+		if databaseMap.NextTickAtUnixSeconds != 0 {
+			return scheduleBucket.Put(scheduleKey(databaseMap.NextTickAtUnixSeconds, databaseMap.ID), []byte{})
+		}
+
+		return nil
+	})
+}
+
+func (repository BoltRepository) ListMapIDsDueToTick(now time.Time) ([]string, error) {
+	mapIDs := []string{}
+	err := repository.database.View(func(transaction *bolt.Tx) error {
+		cursor := transaction.Bucket(scheduleBucketName).Cursor()
+		for key, _ := cursor.First(); key != nil; key, _ = cursor.Next() {
+			if int64(binary.BigEndian.Uint64(key[:8])) > now.Unix() {
+				return nil
+			}
+
+			mapIDs = append(mapIDs, string(key[8:]))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mapIDs, nil
+}
+
+func (repository BoltRepository) ListMaps(query MapQuery) (MapListing, error) {
+	if len(query.Participant) != 0 {
+		return repository.listMapsForParticipant(query)
+	}
+
+	builder := newListingBuilder(query)
+	err := repository.database.View(func(transaction *bolt.Tx) error {
+		cursor := transaction.Bucket(mapBucketName).Cursor()
+
+		mapID, mapBytes := cursor.Seek([]byte(query.Cursor))
+		if mapID != nil && string(mapID) == query.Cursor {
+			mapID, mapBytes = cursor.Next()
+		}
+
+		for ; mapID != nil; mapID, mapBytes = cursor.Next() {
+			databaseMap, err := unmarshalMap(mapBytes)
+			if err != nil {
+				return err
+			}
+
+			if !builder.visit(databaseMap) {
+				return nil
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return MapListing{}, err
+	}
+
+	return builder.listing, nil
+}
+
+func (repository BoltRepository) listMapsForParticipant(query MapQuery) (MapListing, error) {
+	builder := newListingBuilder(query)
+	err := repository.database.View(func(transaction *bolt.Tx) error {
+		mapBucket := transaction.Bucket(mapBucketName)
+		cursor := transaction.Bucket(nationMapBucketName).Cursor()
+
+		prefix := nationMapKey(query.Participant, "")
+		startKey := nationMapKey(query.Participant, query.Cursor)
+
+		key, _ := cursor.Seek(startKey)
+		if key != nil && bytes.Equal(key, startKey) && len(query.Cursor) != 0 {
+			key, _ = cursor.Next()
+		}
+
+		for ; key != nil && bytes.HasPrefix(key, prefix); key, _ = cursor.Next() {
+			mapBytes := mapBucket.Get(key[len(prefix):])
+			if mapBytes == nil {
+				continue
+			}
+
+			databaseMap, err := unmarshalMap(mapBytes)
+			if err != nil {
+				return err
+			}
+
+			if !builder.visit(databaseMap) {
+				return nil
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return MapListing{}, err
+	}
+
+	return builder.listing, nil
+}
+
+func (repository BoltRepository) GetSnapshot(mapID string, year int) (databasemap.DatabaseSnapshot, error) {
+	snapshot := databasemap.DatabaseSnapshot{}
+	err := repository.database.View(func(transaction *bolt.Tx) error {
+		snapshotBytes := transaction.Bucket(snapshotBucketName).Get([]byte(snapshotKey(mapID, year)))
+		if snapshotBytes == nil {
+			return SnapshotDoesntExistError
+		}
+
+		return json.Unmarshal(snapshotBytes, &snapshot)
+	})
+	if err != nil {
+		return databasemap.DatabaseSnapshot{}, err
+	}
+
+	return snapshot, nil
+}
+
+func (repository BoltRepository) PutSnapshot(snapshot databasemap.DatabaseSnapshot) error {
+	snapshotBytes, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	return repository.database.Update(func(transaction *bolt.Tx) error {
+		bucket := transaction.Bucket(snapshotBucketName)
+		key := []byte(snapshotKey(snapshot.MapID, snapshot.Year))
+
+		existingSnapshotBytes := bucket.Get(key)
+		if existingSnapshotBytes != nil {
+			existingSnapshot := databasemap.DatabaseSnapshot{}
+			err := json.Unmarshal(existingSnapshotBytes, &existingSnapshot)
+			if err != nil {
+				return err
+			}
+
+			if existingSnapshot.MapVersion >= snapshot.MapVersion {
+				return SnapshotAlreadyExistsError
+			}
+		}
+
+		return bucket.Put(key, snapshotBytes)
+	})
+}
+
+func (repository BoltRepository) GetSession(nationName string) (dynamodbwrapper.DatabaseSession, error) {
+	databaseSession := dynamodbwrapper.DatabaseSession{}
+	err := repository.database.View(func(transaction *bolt.Tx) error {
+		sessionBytes := transaction.Bucket(sessionBucketName).Get([]byte(nationName))
+		if sessionBytes == nil {
+			return SessionDoesntExistError
+		}
+
+		return json.Unmarshal(sessionBytes, &databaseSession)
+	})
+	if err != nil {
+		return dynamodbwrapper.DatabaseSession{}, err
+	}
+
+	return databaseSession, nil
+}
+
+func (repository BoltRepository) PutSession(databaseSession dynamodbwrapper.DatabaseSession) error {
+	sessionBytes, err := json.Marshal(databaseSession)
+	if err != nil {
+		return err
+	}
+
+	return repository.database.Update(func(transaction *bolt.Tx) error {
+		return transaction.Bucket(sessionBucketName).Put([]byte(databaseSession.NationName), sessionBytes)
+	})
+}
+
+func (repository BoltRepository) DeleteSession(nationName string) error {
+	return repository.database.Update(func(transaction *bolt.Tx) error {
+		return transaction.Bucket(sessionBucketName).Delete([]byte(nationName))
+	})
+}
+
+var boltInterfaceChecker Repository = BoltRepository{}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/brickman1444/NSImperialism/databasemap"
+	"github.com/brickman1444/NSImperialism/dynamodbwrapper"
+	"github.com/stretchr/testify/assert"
+)
+
+func makeTestBoltRepository(t *testing.T) (BoltRepository, string) {
+	directory, err := ioutil.TempDir("", "nsimperialism")
+	assert.NoError(t, err)
+
+	filePath := filepath.Join(directory, "test.db")
+	repository, err := NewBoltRepository(filePath)
+	assert.NoError(t, err)
+
+	return repository, directory
+}
+
+func TestBoltRepositoryGetsAPutMap(t *testing.T) {
+
+	repository, directory := makeTestBoltRepository(t)
+	defer os.RemoveAll(directory)
+	defer repository.Close()
+
+	databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A"})
+	databaseMap.ID = "map1"
+	databaseMap.SetResident("A", "nation1")
+
+	assert.NoError(t, repository.PutMap(databaseMap))
+
+	gotMap, err := repository.GetMap("map1")
+	assert.NoError(t, err)
+	assert.Equal(t, "nation1", gotMap.Cells["A"].Resident)
+
+	listing, err := repository.ListMaps(MapQuery{})
+	assert.NoError(t, err)
+	assert.Len(t, listing.Maps, 1)
+}
+
+func TestBoltRepositoryMissingMapIsAnError(t *testing.T) {
+
+	repository, directory := makeTestBoltRepository(t)
+	defer os.RemoveAll(directory)
+	defer repository.Close()
+
+	_, err := repository.GetMap("map1")
+	assert.Equal(t, MapDoesntExistError, err)
+}
+
+func TestBoltRepositoryKeepsDataAfterReopening(t *testing.T) {
+
+	repository, directory := makeTestBoltRepository(t)
+	defer os.RemoveAll(directory)
+
+	databaseMap := databasemap.NewBlankDatabaseMap()
+	databaseMap.ID = "map1"
+	assert.NoError(t, repository.PutMap(databaseMap))
+	assert.NoError(t, repository.PutSession(dynamodbwrapper.DatabaseSession{NationName: "nation1", SessionID: "session1"}))
+	assert.NoError(t, repository.Close())
+
+	reopenedRepository, err := NewBoltRepository(filepath.Join(directory, "test.db"))
+	assert.NoError(t, err)
+	defer reopenedRepository.Close()
+
+	_, err = reopenedRepository.GetMap("map1")
+	assert.NoError(t, err)
+
+	databaseSession, err := reopenedRepository.GetSession("nation1")
+	assert.NoError(t, err)
+	assert.Equal(t, "session1", databaseSession.SessionID)
+}
+
+func TestBoltRepositorySessionsCanBeDeleted(t *testing.T) {
+
+	repository, directory := makeTestBoltRepository(t)
+	defer os.RemoveAll(directory)
+	defer repository.Close()
+
+	assert.NoError(t, repository.PutSession(dynamodbwrapper.DatabaseSession{NationName: "nation1", SessionID: "session1"}))
+	assert.NoError(t, repository.DeleteSession("nation1"))
+
+	_, err := repository.GetSession("nation1")
+	assert.Equal(t, SessionDoesntExistError, err)
+}
+
+func TestBoltRepositoryPuttingAStaleMapIsAConflict(t *testing.T) {
+
+	repository, directory := makeTestBoltRepository(t)
+	defer os.RemoveAll(directory)
+	defer repository.Close()
+
+	databaseMap := databasemap.NewBlankDatabaseMap()
+	databaseMap.ID = "map1"
+	assert.NoError(t, repository.PutMap(databaseMap))
+
+	firstReader, err := repository.GetMap("map1")
+	assert.NoError(t, err)
+	secondReader, err := repository.GetMap("map1")
+	assert.NoError(t, err)
+
+	assert.NoError(t, repository.PutMap(firstReader))
+
+	err = repository.PutMap(secondReader)
+	assert.True(t, databasemap.IsVersionConflict(err))
+
+	gotMap, err := repository.GetMap("map1")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, gotMap.Version)
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/brickman1444/NSImperialism/databasemap"
+	"github.com/brickman1444/NSImperialism/dynamodbwrapper"
+)
+
+type DynamoDBRepository struct {
+}
+
+func NewDynamoDBRepository() DynamoDBRepository {
+	dynamodbwrapper.Initialize()
+	return DynamoDBRepository{}
+}
+
+func (repository DynamoDBRepository) GetMap(mapID string) (databasemap.DatabaseMap, error) {
+	return dynamodbwrapper.GetMap(mapID)
+}
+
+func (repository DynamoDBRepository) PutMap(databaseMap databasemap.DatabaseMap) error {
+	return dynamodbwrapper.PutMap(databaseMap)
+}
+
+const dynamoDBScanPageSize = 50
+
+func (repository DynamoDBRepository) ListMaps(query MapQuery) (MapListing, error) {
+	if len(query.Participant) != 0 {
+		return repository.listMapsForParticipant(query)
+	}
+
+	builder := newListingBuilder(query)
+
+	exclusiveStartID := query.Cursor
+	for {
+		maps, lastEvaluatedID, err := dynamodbwrapper.ScanMaps(exclusiveStartID, dynamoDBScanPageSize)
+		if err != nil {
+			return MapListing{}, err
+		}
+
+		for _, databaseMap := range maps {
+			if !builder.visit(databaseMap) {
+				return builder.listing, nil
+			}
+		}
+
+		if len(lastEvaluatedID) == 0 {
+			return builder.listing, nil
+		}
+
+		exclusiveStartID = lastEvaluatedID
+	}
+}
+
+func (repository DynamoDBRepository) listMapsForParticipant(query MapQuery) (MapListing, error) {
+	builder := newListingBuilder(query)
+
+	exclusiveStartMapID := query.Cursor
+	for {
+		mapIDs, lastEvaluatedMapID, err := dynamodbwrapper.QueryMapIDsForNation(query.Participant, exclusiveStartMapID, query.limit()+1)
+		if err != nil {
+			return MapListing{}, err
+		}
+
+		for _, mapID := range mapIDs {
+			databaseMap, err := dynamodbwrapper.GetMap(mapID)
+			if err == MapDoesntExistError {
+				continue
+			}
+			if err != nil {
+				return MapListing{}, err
+			}
+
+			if !builder.visit(databaseMap) {
+				return builder.listing, nil
+			}
+		}
+
+		if len(lastEvaluatedMapID) == 0 {
+			return builder.listing, nil
+		}
+
+		exclusiveStartMapID = lastEvaluatedMapID
+	}
+}
+
+func (repository DynamoDBRepository) ListMapIDsDueToTick(now time.Time) ([]string, error) {
+	return dynamodbwrapper.QueryMapIDsDueToTick(now.Unix())
+}
+
+func (repository DynamoDBRepository) GetSnapshot(mapID string, year int) (databasemap.DatabaseSnapshot, error) {
+	return dynamodbwrapper.GetSnapshot(mapID, year)
+}
+
+func (repository DynamoDBRepository) PutSnapshot(snapshot databasemap.DatabaseSnapshot) error {
+	return dynamodbwrapper.PutSnapshot(snapshot)
+}
+
+func (repository DynamoDBRepository) GetSession(nationName string) (dynamodbwrapper.DatabaseSession, error) {
+	return dynamodbwrapper.GetSession(nationName)
+}
+
+func (repository DynamoDBRepository) PutSession(databaseSession dynamodbwrapper.DatabaseSession) error {
+	return dynamodbwrapper.PutSession(databaseSession)
+}
+
+func (repository DynamoDBRepository) DeleteSession(nationName string) error {
+	return dynamodbwrapper.DeleteSession(nationName)
+}
+
+var dynamoDBInterfaceChecker Repository = DynamoDBRepository{}
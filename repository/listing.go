@@ -0,0 +1,54 @@
+package repository
+
+import "github.com/brickman1444/NSImperialism/databasemap"
+
+const DefaultMapListingLimit = 20
+
+type MapQuery struct {
+	Participant string // Only maps where this nation holds a territory. Empty means every map.
+	Cursor      string // NextCursor from the previous listing. Empty means the first page.
+	Limit       int
+}
+
+type MapListing struct {
+	Maps       []databasemap.DatabaseMap
+	NextCursor string // Empty when there are no more pages
+}
+
+func (query MapQuery) limit() int {
+	if query.Limit <= 0 {
+		return DefaultMapListingLimit
+	}
+	return query.Limit
+}
+
+func (query MapQuery) matches(databaseMap databasemap.DatabaseMap) bool {
+	return len(query.Participant) == 0 || databaseMap.HasParticipant(query.Participant)
+}
+
+// listingBuilder is fed maps in storage order and stops once it finds a match that belongs on the next page
+type listingBuilder struct {
+	query   MapQuery
+	listing MapListing
+}
+
+func newListingBuilder(query MapQuery) listingBuilder {
+	return listingBuilder{
+		query:   query,
+		listing: MapListing{Maps: []databasemap.DatabaseMap{}},
+	}
+}
+
+func (builder *listingBuilder) visit(databaseMap databasemap.DatabaseMap) bool {
+	if !builder.query.matches(databaseMap) {
+		return true
+	}
+
+	if len(builder.listing.Maps) == builder.query.limit() {
+		builder.listing.NextCursor = builder.listing.Maps[len(builder.listing.Maps)-1].ID
+		return false
+	}
+
+	builder.listing.Maps = append(builder.listing.Maps, databaseMap)
+	return true
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"os"
+	"testing"
+
+	"github.com/brickman1444/NSImperialism/databasemap"
+	"github.com/stretchr/testify/assert"
+)
+
+func putTestMaps(t *testing.T, repository Repository) {
+	for _, mapID := range []string{"map1", "map2", "map3", "map4", "map5"} {
+		databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A"})
+		databaseMap.ID = mapID
+		if mapID == "map2" || mapID == "map5" {
+			databaseMap.SetResident("A", "player")
+		} else {
+			databaseMap.SetResident("A", "someoneElse")
+		}
+		assert.NoError(t, repository.PutMap(databaseMap))
+	}
+}
+
+func getMapIDs(listing MapListing) []string {
+	mapIDs := []string{}
+	for _, databaseMap := range listing.Maps {
+		mapIDs = append(mapIDs, databaseMap.ID)
+	}
+	return mapIDs
+}
+
+func testListingIsPaginated(t *testing.T, repository Repository) {
+	putTestMaps(t, repository)
+
+	firstPage, err := repository.ListMaps(MapQuery{Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"map1", "map2"}, getMapIDs(firstPage))
+	assert.Equal(t, "map2", firstPage.NextCursor)
+
+	secondPage, err := repository.ListMaps(MapQuery{Limit: 2, Cursor: firstPage.NextCursor})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"map3", "map4"}, getMapIDs(secondPage))
+
+	lastPage, err := repository.ListMaps(MapQuery{Limit: 2, Cursor: secondPage.NextCursor})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"map5"}, getMapIDs(lastPage))
+	assert.Empty(t, lastPage.NextCursor)
+}
+
+func testListingIsFilteredByParticipant(t *testing.T, repository Repository) {
+	putTestMaps(t, repository)
+
+	firstPage, err := repository.ListMaps(MapQuery{Limit: 1, Participant: "player"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"map2"}, getMapIDs(firstPage))
+	assert.NotEmpty(t, firstPage.NextCursor)
+
+	secondPage, err := repository.ListMaps(MapQuery{Limit: 1, Participant: "player", Cursor: firstPage.NextCursor})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"map5"}, getMapIDs(secondPage))
+	assert.Empty(t, secondPage.NextCursor)
+}
+
+func testANationIsNoLongerListedOnceItStopsTakingPart(t *testing.T, repository Repository) {
+	putTestMaps(t, repository)
+
+	databaseMap, err := repository.GetMap("map2")
+	assert.NoError(t, err)
+	databaseMap.Participants = []string{"someoneElse"}
+	assert.NoError(t, repository.PutMap(databaseMap))
+
+	listing, err := repository.ListMaps(MapQuery{Limit: 10, Participant: "player"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"map5"}, getMapIDs(listing))
+
+	listing, err = repository.ListMaps(MapQuery{Limit: 10, Participant: "someoneElse"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"map1", "map2", "map3", "map4"}, getMapIDs(listing))
+}
+
+func TestMemoryRepositoryListingIsPaginated(t *testing.T) {
+	testListingIsPaginated(t, NewMemoryRepository())
+}
+
+func TestMemoryRepositoryListingIsFilteredByParticipant(t *testing.T) {
+	testListingIsFilteredByParticipant(t, NewMemoryRepository())
+}
+
+func TestMemoryRepositoryANationIsNoLongerListedOnceItStopsTakingPart(t *testing.T) {
+	testANationIsNoLongerListedOnceItStopsTakingPart(t, NewMemoryRepository())
+}
+
+func TestBoltRepositoryListingIsPaginated(t *testing.T) {
+	repository, directory := makeTestBoltRepository(t)
+	defer os.RemoveAll(directory)
+	defer repository.Close()
+
+	testListingIsPaginated(t, repository)
+}
+
+func TestBoltRepositoryListingIsFilteredByParticipant(t *testing.T) {
+	repository, directory := makeTestBoltRepository(t)
+	defer os.RemoveAll(directory)
+	defer repository.Close()
+
+	testListingIsFilteredByParticipant(t, repository)
+}
+
+func TestBoltRepositoryANationIsNoLongerListedOnceItStopsTakingPart(t *testing.T) {
+	repository, directory := makeTestBoltRepository(t)
+	defer os.RemoveAll(directory)
+	defer repository.Close()
+
+	testANationIsNoLongerListedOnceItStopsTakingPart(t, repository)
+}
@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/brickman1444/NSImperialism/databasemap"
+	"github.com/brickman1444/NSImperialism/dynamodbwrapper"
+)
+
+// MemoryRepository keeps everything in process so the game can run locally without AWS credentials
+type MemoryRepository struct {
+	maps       map[string][]byte
+	nationMaps map[string]map[string]bool
+	nextTicks  map[string]int64
+	snapshots  map[string][]byte
+	sessions   map[string]dynamodbwrapper.DatabaseSession
+	mutex      *sync.Mutex
+}
+
+func NewMemoryRepository() MemoryRepository {
+	return MemoryRepository{
+		maps:       make(map[string][]byte),
+		nationMaps: make(map[string]map[string]bool),
+		nextTicks:  make(map[string]int64),
+		snapshots:  make(map[string][]byte),
+		sessions:   make(map[string]dynamodbwrapper.DatabaseSession),
+		mutex:      &sync.Mutex{},
+	}
+}
+
+func unmarshalMap(mapBytes []byte) (databasemap.DatabaseMap, error) {
+	databaseMap := databasemap.NewBlankDatabaseMap()
+	err := json.Unmarshal(mapBytes, &databaseMap)
+	if err != nil {
+		return databasemap.NewBlankDatabaseMap(), err
+	}
+	return databaseMap, nil
+}
+
+func (repository MemoryRepository) GetMap(mapID string) (databasemap.DatabaseMap, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	mapBytes, doesMapExist := repository.maps[mapID]
+	if !doesMapExist {
+		return databasemap.NewBlankDatabaseMap(), MapDoesntExistError
+	}
+
+	return unmarshalMap(mapBytes)
+}
+
+func (repository MemoryRepository) PutMap(databaseMap databasemap.DatabaseMap) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	storedVersion := 0
+	staleParticipants := []string{}
+	storedMapBytes, doesMapExist := repository.maps[databaseMap.ID]
+	if doesMapExist {
+		storedMap, err := unmarshalMap(storedMapBytes)
+		if err != nil {
+			return err
+		}
+		storedVersion = storedMap.Version
+		staleParticipants = storedMap.ParticipantsMissingFrom(databaseMap)
+	}
+
+	if storedVersion != databaseMap.Version {
+		return databasemap.VersionConflictError{MapID: databaseMap.ID}
+	}
+
+	databaseMap.Version++
+	databaseMap.ScheduleNextTick()
+
+	mapBytes, err := json.Marshal(databaseMap)
+	if err != nil {
+		return err
+	}
+
+	repository.maps[databaseMap.ID] = mapBytes
+
+	delete(repository.nextTicks, databaseMap.ID)
+	if databaseMap.NextTickAtUnixSeconds != 0 {
+		repository.nextTicks[databaseMap.ID] = databaseMap.NextTickAtUnixSeconds
+	}
+
+	for _, participant := range staleParticipants {
+		delete(repository.nationMaps[participant], databaseMap.ID)
+	}
+
+	for _, participant := range databaseMap.Participants {
+		_, doesNationHaveMaps := repository.nationMaps[participant]
+		if !doesNationHaveMaps {
+			repository.nationMaps[participant] = make(map[string]bool)
+		}
+		repository.nationMaps[participant][databaseMap.ID] = true
+	}
+
+	return nil
+}
+
+func (repository MemoryRepository) ListMaps(query MapQuery) (MapListing, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	mapsToList := repository.maps
+	if len(query.Participant) != 0 {
+		mapsToList = make(map[string][]byte)
+		for mapID := range repository.nationMaps[query.Participant] {
+			mapsToList[mapID] = repository.maps[mapID]
+		}
+	}
+
+	mapIDs := []string{}
+	for mapID := range mapsToList {
+		if mapID > query.Cursor {
+			mapIDs = append(mapIDs, mapID)
+		}
+	}
+	sort.Strings(mapIDs)
+
+	builder := newListingBuilder(query)
+	for _, mapID := range mapIDs {
+		databaseMap, err := unmarshalMap(repository.maps[mapID])
+		if err != nil {
+			return MapListing{}, err
+		}
+
+		if !builder.visit(databaseMap) {
+			break
+		}
+	}
+
+	return builder.listing, nil
+}
+
+func (repository MemoryRepository) ListMapIDsDueToTick(now time.Time) ([]string, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	mapIDs := []string{}
+	for mapID, nextTickAtUnixSeconds := range repository.nextTicks {
+		if nextTickAtUnixSeconds <= now.Unix() {
+			mapIDs = append(mapIDs, mapID)
+		}
+	}
+	sort.Slice(mapIDs, func(i, j int) bool {
+		if repository.nextTicks[mapIDs[i]] != repository.nextTicks[mapIDs[j]] {
+			return repository.nextTicks[mapIDs[i]] < repository.nextTicks[mapIDs[j]]
+		}
+		return mapIDs[i] < mapIDs[j]
+	})
+
+	return mapIDs, nil
+}
+
+func (repository MemoryRepository) GetSnapshot(mapID string, year int) (databasemap.DatabaseSnapshot, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	snapshotBytes, doesSnapshotExist := repository.snapshots[snapshotKey(mapID, year)]
+	if !doesSnapshotExist {
+		return databasemap.DatabaseSnapshot{}, SnapshotDoesntExistError
+	}
+
+	snapshot := databasemap.DatabaseSnapshot{}
+	err := json.Unmarshal(snapshotBytes, &snapshot)
+	if err != nil {
+		return databasemap.DatabaseSnapshot{}, err
+	}
+
+	return snapshot, nil
+}
+
+func (repository MemoryRepository) PutSnapshot(snapshot databasemap.DatabaseSnapshot) error {
+	snapshotBytes, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	key := snapshotKey(snapshot.MapID, snapshot.Year)
+	existingSnapshotBytes, doesSnapshotExist := repository.snapshots[key]
+	if doesSnapshotExist {
+		existingSnapshot := databasemap.DatabaseSnapshot{}
+		err = json.Unmarshal(existingSnapshotBytes, &existingSnapshot)
+		if err != nil {
+			return err
+		}
+
+		if existingSnapshot.MapVersion >= snapshot.MapVersion {
+			return SnapshotAlreadyExistsError
+		}
+	}
+
+	repository.snapshots[key] = snapshotBytes
+
+	return nil
+}
+
+func (repository MemoryRepository) GetSession(nationName string) (dynamodbwrapper.DatabaseSession, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	databaseSession, doesSessionExist := repository.sessions[nationName]
+	if !doesSessionExist {
+		return dynamodbwrapper.DatabaseSession{}, SessionDoesntExistError
+	}
+
+	return databaseSession, nil
+}
+
+func (repository MemoryRepository) PutSession(databaseSession dynamodbwrapper.DatabaseSession) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	repository.sessions[databaseSession.NationName] = databaseSession
+
+	return nil
+}
+
+func (repository MemoryRepository) DeleteSession(nationName string) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	delete(repository.sessions, nationName)
+
+	return nil
+}
+
+var memoryInterfaceChecker Repository = MemoryRepository{}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/brickman1444/NSImperialism/databasemap"
+	"github.com/brickman1444/NSImperialism/dynamodbwrapper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryRepositoryGetsAPutMap(t *testing.T) {
+
+	repository := NewMemoryRepository()
+
+	databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A"})
+	databaseMap.ID = "map1"
+	databaseMap.SetResident("A", "nation1")
+
+	assert.NoError(t, repository.PutMap(databaseMap))
+
+	gotMap, err := repository.GetMap("map1")
+	assert.NoError(t, err)
+	assert.Equal(t, "map1", gotMap.ID)
+	assert.Equal(t, "nation1", gotMap.Cells["A"].Resident)
+}
+
+func TestMemoryRepositoryMissingMapIsAnError(t *testing.T) {
+
+	repository := NewMemoryRepository()
+
+	_, err := repository.GetMap("map1")
+	assert.Equal(t, MapDoesntExistError, err)
+}
+
+func TestMemoryRepositoryChangingAGotMapDoesntChangeTheStoredMap(t *testing.T) {
+
+	repository := NewMemoryRepository()
+
+	databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A"})
+	databaseMap.ID = "map1"
+	databaseMap.SetResident("A", "nation1")
+	assert.NoError(t, repository.PutMap(databaseMap))
+
+	gotMap, err := repository.GetMap("map1")
+	assert.NoError(t, err)
+	gotMap.SetResident("A", "nation2")
+
+	gotMapAgain, err := repository.GetMap("map1")
+	assert.NoError(t, err)
+	assert.Equal(t, "nation1", gotMapAgain.Cells["A"].Resident)
+}
+
+func TestMemoryRepositoryListsMaps(t *testing.T) {
+
+	repository := NewMemoryRepository()
+
+	for _, mapID := range []string{"map2", "map1"} {
+		databaseMap := databasemap.NewBlankDatabaseMap()
+		databaseMap.ID = mapID
+		assert.NoError(t, repository.PutMap(databaseMap))
+	}
+
+	listing, err := repository.ListMaps(MapQuery{})
+	assert.NoError(t, err)
+	assert.Len(t, listing.Maps, 2)
+	assert.Equal(t, "map1", listing.Maps[0].ID)
+	assert.Equal(t, "map2", listing.Maps[1].ID)
+	assert.Empty(t, listing.NextCursor)
+}
+
+func TestMemoryRepositorySessionsCanBePutGotAndDeleted(t *testing.T) {
+
+	repository := NewMemoryRepository()
+
+	_, err := repository.GetSession("nation1")
+	assert.Equal(t, SessionDoesntExistError, err)
+
+	assert.NoError(t, repository.PutSession(dynamodbwrapper.DatabaseSession{NationName: "nation1", SessionID: "session1", ExpiresAtUnixSeconds: 10}))
+
+	databaseSession, err := repository.GetSession("nation1")
+	assert.NoError(t, err)
+	assert.Equal(t, "session1", databaseSession.SessionID)
+
+	assert.NoError(t, repository.DeleteSession("nation1"))
+
+	_, err = repository.GetSession("nation1")
+	assert.Equal(t, SessionDoesntExistError, err)
+}
+
+func TestUnknownBackendIsAnError(t *testing.T) {
+
+	_, err := New("carrier pigeon")
+	assert.Error(t, err)
+}
+
+func TestMemoryRepositoryPutIncrementsTheVersion(t *testing.T) {
+
+	repository := NewMemoryRepository()
+
+	databaseMap := databasemap.NewBlankDatabaseMap()
+	databaseMap.ID = "map1"
+	assert.NoError(t, repository.PutMap(databaseMap))
+
+	gotMap, err := repository.GetMap("map1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, gotMap.Version)
+
+	assert.NoError(t, repository.PutMap(gotMap))
+
+	gotMapAgain, err := repository.GetMap("map1")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, gotMapAgain.Version)
+}
+
+func TestMemoryRepositoryPuttingAStaleMapIsAConflict(t *testing.T) {
+
+	repository := NewMemoryRepository()
+
+	databaseMap := databasemap.NewBlankDatabaseMap()
+	databaseMap.ID = "map1"
+	assert.NoError(t, repository.PutMap(databaseMap))
+
+	firstReader, err := repository.GetMap("map1")
+	assert.NoError(t, err)
+	secondReader, err := repository.GetMap("map1")
+	assert.NoError(t, err)
+
+	assert.NoError(t, repository.PutMap(firstReader))
+
+	err = repository.PutMap(secondReader)
+	assert.True(t, databasemap.IsVersionConflict(err))
+}
+
+func TestMemoryRepositoryCreatingAMapThatAlreadyExistsIsAConflict(t *testing.T) {
+
+	repository := NewMemoryRepository()
+
+	databaseMap := databasemap.NewBlankDatabaseMap()
+	databaseMap.ID = "map1"
+	assert.NoError(t, repository.PutMap(databaseMap))
+
+	err := repository.PutMap(databaseMap)
+	assert.True(t, databasemap.IsVersionConflict(err))
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/brickman1444/NSImperialism/databasemap"
+	"github.com/brickman1444/NSImperialism/dynamodbwrapper"
+)
+
+var MapDoesntExistError = dynamodbwrapper.MapDoesntExistError
+var SessionDoesntExistError = dynamodbwrapper.SessionDoesntExistError
+var SnapshotDoesntExistError = dynamodbwrapper.SnapshotDoesntExistError
+var SnapshotAlreadyExistsError = dynamodbwrapper.SnapshotAlreadyExistsError
+
+// PutMap fails with a databasemap.VersionConflictError if the map was saved since it was read and PutSnapshot only replaces snapshots from older versions of the map
+type Repository interface {
+	GetMap(mapID string) (databasemap.DatabaseMap, error)
+	PutMap(databaseMap databasemap.DatabaseMap) error
+	ListMaps(query MapQuery) (MapListing, error)
+	ListMapIDsDueToTick(now time.Time) ([]string, error)
+	GetSnapshot(mapID string, year int) (databasemap.DatabaseSnapshot, error)
+	PutSnapshot(snapshot databasemap.DatabaseSnapshot) error
+	GetSession(nationName string) (dynamodbwrapper.DatabaseSession, error)
+	PutSession(databaseSession dynamodbwrapper.DatabaseSession) error
+	DeleteSession(nationName string) error
+}
+
+const BackendDynamoDB = "dynamodb"
+const BackendMemory = "memory"
+const BackendBolt = "bolt"
+
+func New(backend string) (Repository, error) {
+	switch backend {
+	case "", BackendDynamoDB:
+		return NewDynamoDBRepository(), nil
+	case BackendMemory:
+		return NewMemoryRepository(), nil
+	case BackendBolt:
+		return NewBoltRepository(boltFilePath())
+	}
+
+	return nil, fmt.Errorf("Unknown storage backend '%s'", backend)
+}
+
+func snapshotKey(mapID string, year int) string {
+	return fmt.Sprintf("%s\x00%d", mapID, year)
+}
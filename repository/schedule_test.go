@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/brickman1444/NSImperialism/databasemap"
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+var testYearStart = time.Date(2021, 3, 14, 9, 26, 0, 0, time.UTC)
+
+func putScheduledTestMap(t *testing.T, repository Repository, mapID string, tickCadence databasemap.Cadence) {
+	databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A"})
+	databaseMap.ID = mapID
+	databaseMap.TickCadence = tickCadence
+	databaseMap.StartOrderPhase(testYearStart)
+	assert.NoError(t, repository.PutMap(databaseMap))
+}
+
+func testOnlyMapsThatAreDueAreListedToTick(t *testing.T, repository Repository) {
+	putScheduledTestMap(t, repository, "hourly", databasemap.HourlyCadence)
+	putScheduledTestMap(t, repository, "daily", databasemap.DailyCadence)
+	putScheduledTestMap(t, repository, "manual", databasemap.ManualCadence)
+	putScheduledTestMap(t, repository, "finished", databasemap.HourlyCadence)
+
+	finishedMap, err := repository.GetMap("finished")
+	assert.NoError(t, err)
+	finishedMap.IsFinished = true
+	assert.NoError(t, repository.PutMap(finishedMap))
+
+	mapIDs, err := repository.ListMapIDsDueToTick(testYearStart)
+	assert.NoError(t, err)
+	assert.Empty(t, mapIDs)
+
+	mapIDs, err = repository.ListMapIDsDueToTick(testYearStart.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hourly"}, mapIDs)
+
+	mapIDs, err = repository.ListMapIDsDueToTick(testYearStart.Add(24 * time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hourly", "daily"}, mapIDs)
+}
+
+func testAMapIsNoLongerDueOnceItsYearIsResolved(t *testing.T, repository Repository) {
+	putScheduledTestMap(t, repository, "hourly", databasemap.HourlyCadence)
+
+	databaseMap, err := repository.GetMap("hourly")
+	assert.NoError(t, err)
+	databaseMap.StartOrderPhase(testYearStart.Add(time.Hour))
+	assert.NoError(t, repository.PutMap(databaseMap))
+
+	mapIDs, err := repository.ListMapIDsDueToTick(testYearStart.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Empty(t, mapIDs)
+}
+
+func TestMemoryRepositoryOnlyMapsThatAreDueAreListedToTick(t *testing.T) {
+	testOnlyMapsThatAreDueAreListedToTick(t, NewMemoryRepository())
+}
+
+func TestMemoryRepositoryAMapIsNoLongerDueOnceItsYearIsResolved(t *testing.T) {
+	testAMapIsNoLongerDueOnceItsYearIsResolved(t, NewMemoryRepository())
+}
+
+func TestBoltRepositoryOnlyMapsThatAreDueAreListedToTick(t *testing.T) {
+	repository, directory := makeTestBoltRepository(t)
+	defer os.RemoveAll(directory)
+	defer repository.Close()
+
+	testOnlyMapsThatAreDueAreListedToTick(t, repository)
+}
+
+func TestBoltRepositoryAMapIsNoLongerDueOnceItsYearIsResolved(t *testing.T) {
+	repository, directory := makeTestBoltRepository(t)
+	defer os.RemoveAll(directory)
+	defer repository.Close()
+
+	testAMapIsNoLongerDueOnceItsYearIsResolved(t, repository)
+}
+
+func TestBoltRepositorySchedulesMapsSavedBeforeTheScheduleExisted(t *testing.T) {
+	repository, directory := makeTestBoltRepository(t)
+	defer os.RemoveAll(directory)
+
+	putScheduledTestMap(t, repository, "hourly", databasemap.HourlyCadence)
+	assert.NoError(t, repository.database.Update(func(transaction *bolt.Tx) error {
+		return transaction.DeleteBucket(scheduleBucketName)
+	}))
+	assert.NoError(t, repository.Close())
+
+	reopenedRepository, err := NewBoltRepository(filepath.Join(directory, "test.db"))
+	assert.NoError(t, err)
+	defer reopenedRepository.Close()
+
+	mapIDs, err := reopenedRepository.ListMapIDsDueToTick(testYearStart.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hourly"}, mapIDs)
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"os"
+	"testing"
+
+	"github.com/brickman1444/NSImperialism/databasemap"
+	"github.com/stretchr/testify/assert"
+)
+
+func testSnapshotsAreStoredPerYear(t *testing.T, repository Repository) {
+	databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A"})
+	databaseMap.ID = "map1"
+	databaseMap.SetResident("A", "nation1")
+	assert.NoError(t, repository.PutSnapshot(databasemap.NewSnapshot(databaseMap)))
+
+	databaseMap.Year++
+	databaseMap.SetResident("A", "nation2")
+	assert.NoError(t, repository.PutSnapshot(databasemap.NewSnapshot(databaseMap)))
+
+	yearZero, err := repository.GetSnapshot("map1", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "nation1", yearZero.Cells["A"].Resident)
+
+	yearOne, err := repository.GetSnapshot("map1", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "nation2", yearOne.Cells["A"].Resident)
+
+	_, err = repository.GetSnapshot("map1", 2)
+	assert.Equal(t, SnapshotDoesntExistError, err)
+}
+
+func testSnapshotsCantBeOverwritten(t *testing.T, repository Repository) {
+	databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A"})
+	databaseMap.ID = "map1"
+	databaseMap.SetResident("A", "nation1")
+	assert.NoError(t, repository.PutSnapshot(databasemap.NewSnapshot(databaseMap)))
+
+	databaseMap.SetResident("A", "nation2")
+	assert.Equal(t, SnapshotAlreadyExistsError, repository.PutSnapshot(databasemap.NewSnapshot(databaseMap)))
+
+	snapshot, err := repository.GetSnapshot("map1", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "nation1", snapshot.Cells["A"].Resident)
+}
+
+func testSnapshotsFromALaterMapVersionReplaceOlderOnes(t *testing.T, repository Repository) {
+	databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A"})
+	databaseMap.ID = "map1"
+	databaseMap.Version = 3
+	databaseMap.SetResident("A", "nation1")
+	assert.NoError(t, repository.PutSnapshot(databasemap.NewSnapshot(databaseMap)))
+
+	databaseMap.Version = 4
+	databaseMap.SetResident("A", "nation2")
+	assert.NoError(t, repository.PutSnapshot(databasemap.NewSnapshot(databaseMap)))
+
+	databaseMap.Version = 3
+	databaseMap.SetResident("A", "nation3")
+	assert.Equal(t, SnapshotAlreadyExistsError, repository.PutSnapshot(databasemap.NewSnapshot(databaseMap)))
+
+	snapshot, err := repository.GetSnapshot("map1", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "nation2", snapshot.Cells["A"].Resident)
+}
+
+func TestMemoryRepositorySnapshotsAreStoredPerYear(t *testing.T) {
+	testSnapshotsAreStoredPerYear(t, NewMemoryRepository())
+}
+
+func TestMemoryRepositorySnapshotsCantBeOverwritten(t *testing.T) {
+	testSnapshotsCantBeOverwritten(t, NewMemoryRepository())
+}
+
+func TestBoltRepositorySnapshotsAreStoredPerYear(t *testing.T) {
+	repository, directory := makeTestBoltRepository(t)
+	defer os.RemoveAll(directory)
+	defer repository.Close()
+
+	testSnapshotsAreStoredPerYear(t, repository)
+}
+
+func TestBoltRepositorySnapshotsCantBeOverwritten(t *testing.T) {
+	repository, directory := makeTestBoltRepository(t)
+	defer os.RemoveAll(directory)
+	defer repository.Close()
+
+	testSnapshotsCantBeOverwritten(t, repository)
+}
+
+func TestMemoryRepositorySnapshotsFromALaterMapVersionReplaceOlderOnes(t *testing.T) {
+	testSnapshotsFromALaterMapVersionReplaceOlderOnes(t, NewMemoryRepository())
+}
+
+func TestBoltRepositorySnapshotsFromALaterMapVersionReplaceOlderOnes(t *testing.T) {
+	repository, directory := makeTestBoltRepository(t)
+	defer os.RemoveAll(directory)
+	defer repository.Close()
+
+	testSnapshotsFromALaterMapVersionReplaceOlderOnes(t, repository)
+}
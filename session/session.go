@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/brickman1444/NSImperialism/dynamodbwrapper"
+	"github.com/brickman1444/NSImperialism/repository"
 )
 
 type Session struct {
@@ -63,12 +64,17 @@ func (manager *SessionManagerSimpleMap) RemoveSession(nationName string) error {
 
 var simpleMapInterfaceChecker SessionManager = &SessionManagerSimpleMap{}
 
-type SessionManagerDatabase struct {
+type SessionManagerRepository struct {
+	repository repository.Repository
 }
 
-func (manager *SessionManagerDatabase) IsValidSession(nationName string, sessionIDString string, now time.Time) (bool, error) {
+func NewSessionManagerRepository(repository repository.Repository) SessionManagerRepository {
+	return SessionManagerRepository{repository: repository}
+}
+
+func (manager *SessionManagerRepository) IsValidSession(nationName string, sessionIDString string, now time.Time) (bool, error) {
 
-	session, err := dynamodbwrapper.GetSession(nationName)
+	session, err := manager.repository.GetSession(nationName)
 	if err != nil {
 		return false, err
 	}
@@ -78,7 +84,7 @@ func (manager *SessionManagerDatabase) IsValidSession(nationName string, session
 	return session.SessionID == sessionIDString && expirationDate.After(now), nil
 }
 
-func (manager *SessionManagerDatabase) AddSession(nationName string, sessionIDString string, expires time.Time) error {
+func (manager *SessionManagerRepository) AddSession(nationName string, sessionIDString string, expires time.Time) error {
 
 	databaseSession := dynamodbwrapper.DatabaseSession{
 		NationName:           nationName,
@@ -86,12 +92,12 @@ func (manager *SessionManagerDatabase) AddSession(nationName string, sessionIDSt
 		ExpiresAtUnixSeconds: expires.Unix(),
 	}
 
-	return dynamodbwrapper.PutSession(databaseSession)
+	return manager.repository.PutSession(databaseSession)
 }
 
-func (manager *SessionManagerDatabase) RemoveSession(nationName string) error {
+func (manager *SessionManagerRepository) RemoveSession(nationName string) error {
 
-	return dynamodbwrapper.DeleteSession(nationName)
+	return manager.repository.DeleteSession(nationName)
 }
 
-var databaseInterfaceChecker SessionManager = &SessionManagerDatabase{}
+var repositoryInterfaceChecker SessionManager = &SessionManagerRepository{}
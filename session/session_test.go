@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/brickman1444/NSImperialism/repository"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -72,3 +73,29 @@ func TestSessionManagerRemovedSessionIsntValid(t *testing.T) {
 	assert.False(t, isValid)
 	assert.NoError(t, err)
 }
+
+func TestRepositorySessionManagerFindsValidSession(t *testing.T) {
+
+	manager := NewSessionManagerRepository(repository.NewMemoryRepository())
+
+	tenTen, _ := time.Parse(time.RFC3339, "2010-10-10T10:10:00Z")
+	manager.AddSession("nationA", "session1", tenTen)
+
+	ten, _ := time.Parse(time.RFC3339, "2010-10-10T10:00:00Z")
+	isValid, err := manager.IsValidSession("nationA", "session1", ten)
+	assert.True(t, isValid)
+	assert.NoError(t, err)
+}
+
+func TestRepositorySessionManagerRemovedSessionIsntValid(t *testing.T) {
+
+	manager := NewSessionManagerRepository(repository.NewMemoryRepository())
+
+	tenTen, _ := time.Parse(time.RFC3339, "2010-10-10T10:10:00Z")
+	manager.AddSession("nationA", "session1", tenTen)
+	manager.RemoveSession("nationA")
+
+	ten, _ := time.Parse(time.RFC3339, "2010-10-10T10:00:00Z")
+	isValid, _ := manager.IsValidSession("nationA", "session1", ten)
+	assert.False(t, isValid)
+}
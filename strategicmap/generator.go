@@ -0,0 +1,321 @@
+package strategicmap
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+
+	"github.com/brickman1444/NSImperialism/databasemap"
+)
+
+const GeneratedLayoutIDPrefix = "generated-"
+const MinimumGeneratedTerritories = 6
+const MaximumGeneratedTerritories = 40
+const DefaultGeneratedTerritories = 18
+
+const generatedWidthPX = 1536
+const generatedHeightPX = 723
+const relaxationPasses = 2
+
+type Point struct {
+	X float64
+	Y float64
+}
+
+// GenerateLayout splits the board into a Voronoi region around a random point for each territory
+func GenerateLayout(territoryCount int, seed int64) (Map, error) {
+	if territoryCount < MinimumGeneratedTerritories || territoryCount > MaximumGeneratedTerritories {
+		return Map{}, fmt.Errorf("A generated map must have between %d and %d territories", MinimumGeneratedTerritories, MaximumGeneratedTerritories)
+	}
+
+	random := rand.New(rand.NewSource(seed))
+
+	sites := placeSites(territoryCount, random)
+	outlines := getVoronoiCells(sites)
+
+	// moving each point to the middle of its region evens out the sizes of the territories
+	for pass := 0; pass < relaxationPasses; pass++ {
+		for siteIndex := range sites {
+			sites[siteIndex] = getCentroid(outlines[siteIndex])
+		}
+		outlines = getVoronoiCells(sites)
+	}
+
+	id := fmt.Sprintf("%s%d-%d", GeneratedLayoutIDPrefix, territoryCount, seed)
+	layout := Map{
+		ID:          id,
+		Name:        fmt.Sprintf("Generated Board of %d Territories", territoryCount),
+		WidthPX:     generatedWidthPX,
+		HeightPX:    generatedHeightPX,
+		Territories: []Territory{},
+		Borders:     []Border{},
+		Outlines:    make(map[string][]Point),
+	}
+
+	inlandTerrains := []databasemap.Terrain{databasemap.Plains, databasemap.Plains, databasemap.Forest, databasemap.Hills, databasemap.Mountains, databasemap.Marsh}
+
+	for siteIndex, outline := range outlines {
+		territoryID := getGeneratedTerritoryID(siteIndex)
+		labelPosition := getCentroid(outline)
+
+		terrain := databasemap.Coast
+		if !touchesEdge(outline) {
+			terrain = inlandTerrains[random.Intn(len(inlandTerrains))]
+		}
+
+		layout.Territories = append(layout.Territories, Territory{
+			ID:      territoryID,
+			LeftPX:  int(math.Round(labelPosition.X)),
+			TopPX:   int(math.Round(labelPosition.Y)),
+			Terrain: terrain,
+		})
+		layout.Outlines[territoryID] = outline
+	}
+
+	for siteIndex := range sites {
+		for otherSiteIndex := siteIndex + 1; otherSiteIndex < len(sites); otherSiteIndex++ {
+			if doCellsShareAnEdge(outlines[siteIndex], sites[siteIndex], sites[otherSiteIndex]) {
+				layout.Borders = append(layout.Borders, Border{A: getGeneratedTerritoryID(siteIndex), B: getGeneratedTerritoryID(otherSiteIndex)})
+			}
+		}
+	}
+
+	return layout, nil
+}
+
+func (strategicMap Map) IsGenerated() bool {
+	return strings.HasPrefix(strategicMap.ID, GeneratedLayoutIDPrefix)
+}
+
+func (strategicMap Map) ToDatabaseLayout() databasemap.DatabaseLayout {
+	databaseLayout := databasemap.DatabaseLayout{
+		Name:        strategicMap.Name,
+		WidthPX:     strategicMap.WidthPX,
+		HeightPX:    strategicMap.HeightPX,
+		Territories: []databasemap.DatabaseLayoutTerritory{},
+		Borders:     []databasemap.DatabaseBorder{},
+	}
+
+	for _, territory := range strategicMap.Territories {
+		outline := []databasemap.DatabasePoint{}
+		for _, point := range strategicMap.Outlines[territory.ID] {
+			outline = append(outline, databasemap.DatabasePoint{X: point.X, Y: point.Y})
+		}
+
+		databaseLayout.Territories = append(databaseLayout.Territories, databasemap.DatabaseLayoutTerritory{
+			ID:      territory.ID,
+			LeftPX:  territory.LeftPX,
+			TopPX:   territory.TopPX,
+			Terrain: territory.Terrain,
+			Outline: outline,
+		})
+	}
+
+	for _, border := range strategicMap.Borders {
+		databaseLayout.Borders = append(databaseLayout.Borders, databasemap.DatabaseBorder{A: border.A, B: border.B})
+	}
+
+	return databaseLayout
+}
+
+// FromDatabaseLayout rebuilds the generated layout stored with a map, drawing its background from the outlines
+func FromDatabaseLayout(layoutID string, mapID string, databaseLayout databasemap.DatabaseLayout) Map {
+	layout := Map{
+		ID:              layoutID,
+		Name:            databaseLayout.Name,
+		WidthPX:         databaseLayout.WidthPX,
+		HeightPX:        databaseLayout.HeightPX,
+		BackgroundImage: "/maps/" + mapID + "/background.svg",
+		Territories:     []Territory{},
+		Borders:         []Border{},
+		Outlines:        make(map[string][]Point),
+	}
+
+	for _, territory := range databaseLayout.Territories {
+		outline := []Point{}
+		for _, point := range territory.Outline {
+			outline = append(outline, Point{X: point.X, Y: point.Y})
+		}
+
+		layout.Territories = append(layout.Territories, Territory{
+			ID:      territory.ID,
+			LeftPX:  territory.LeftPX,
+			TopPX:   territory.TopPX,
+			Terrain: territory.Terrain,
+		})
+		layout.Outlines[territory.ID] = outline
+	}
+
+	for _, border := range databaseLayout.Borders {
+		layout.Borders = append(layout.Borders, Border{A: border.A, B: border.B})
+	}
+
+	return layout
+}
+
+// placeSites puts each point in a different cell of a grid so no two territories start on top of each other
+func placeSites(territoryCount int, random *rand.Rand) []Point {
+	rows := int(math.Round(math.Sqrt(float64(territoryCount) * generatedHeightPX / generatedWidthPX)))
+	if rows < 1 {
+		rows = 1
+	}
+	columns := (territoryCount + rows - 1) / rows
+
+	cellWidth := float64(generatedWidthPX) / float64(columns)
+	cellHeight := float64(generatedHeightPX) / float64(rows)
+
+	gridCells := random.Perm(rows * columns)[:territoryCount]
+
+	sites := []Point{}
+	for _, gridCell := range gridCells {
+		column := gridCell % columns
+		row := gridCell / columns
+		sites = append(sites, Point{
+			X: (float64(column) + 0.2 + 0.6*random.Float64()) * cellWidth,
+			Y: (float64(row) + 0.2 + 0.6*random.Float64()) * cellHeight,
+		})
+	}
+	return sites
+}
+
+// getVoronoiCells cuts the board down to the points closer to each site than to any other site
+func getVoronoiCells(sites []Point) [][]Point {
+	board := []Point{{0, 0}, {generatedWidthPX, 0}, {generatedWidthPX, generatedHeightPX}, {0, generatedHeightPX}}
+
+	cells := [][]Point{}
+	for siteIndex, site := range sites {
+		cell := board
+		for otherSiteIndex, otherSite := range sites {
+			if otherSiteIndex != siteIndex {
+				cell = clipToCloserHalf(cell, site, otherSite)
+			}
+		}
+		cells = append(cells, cell)
+	}
+	return cells
+}
+
+// distancePastBisector is negative on the site's side of the line halfway between the two sites and positive on the other site's side
+func distancePastBisector(point Point, site Point, otherSite Point) float64 {
+	directionX := otherSite.X - site.X
+	directionY := otherSite.Y - site.Y
+	midpointX := (site.X + otherSite.X) / 2
+	midpointY := (site.Y + otherSite.Y) / 2
+	return ((point.X-midpointX)*directionX + (point.Y-midpointY)*directionY) / math.Hypot(directionX, directionY)
+}
+
+func clipToCloserHalf(polygon []Point, site Point, otherSite Point) []Point {
+	clipped := []Point{}
+	for vertexIndex, vertex := range polygon {
+		previous := polygon[(vertexIndex+len(polygon)-1)%len(polygon)]
+		vertexDistance := distancePastBisector(vertex, site, otherSite)
+		previousDistance := distancePastBisector(previous, site, otherSite)
+
+		if (vertexDistance <= 0) != (previousDistance <= 0) {
+			fraction := previousDistance / (previousDistance - vertexDistance)
+			clipped = append(clipped, Point{
+				X: previous.X + fraction*(vertex.X-previous.X),
+				Y: previous.Y + fraction*(vertex.Y-previous.Y),
+			})
+		}
+
+		if vertexDistance <= 0 {
+			clipped = append(clipped, vertex)
+		}
+	}
+	return clipped
+}
+
+// doCellsShareAnEdge needs two corners on the line between the sites since cells that only meet at a corner aren't neighbours
+func doCellsShareAnEdge(cell []Point, site Point, otherSite Point) bool {
+	const tolerancePX = 0.001
+	const minimumBorderPX = 1
+
+	pointsOnBisector := []Point{}
+	for _, vertex := range cell {
+		if math.Abs(distancePastBisector(vertex, site, otherSite)) < tolerancePX {
+			pointsOnBisector = append(pointsOnBisector, vertex)
+		}
+	}
+
+	for _, point := range pointsOnBisector {
+		for _, otherPoint := range pointsOnBisector {
+			if math.Hypot(point.X-otherPoint.X, point.Y-otherPoint.Y) >= minimumBorderPX {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func getCentroid(polygon []Point) Point {
+	area := 0.0
+	centroid := Point{}
+	for vertexIndex, vertex := range polygon {
+		next := polygon[(vertexIndex+1)%len(polygon)]
+		cross := vertex.X*next.Y - next.X*vertex.Y
+		area += cross
+		centroid.X += (vertex.X + next.X) * cross
+		centroid.Y += (vertex.Y + next.Y) * cross
+	}
+	area /= 2
+	centroid.X /= 6 * area
+	centroid.Y /= 6 * area
+	return centroid
+}
+
+// touchesEdge finds territories on the edge of the board which are treated as coast
+func touchesEdge(polygon []Point) bool {
+	const tolerancePX = 0.001
+	for _, vertex := range polygon {
+		if vertex.X < tolerancePX || vertex.Y < tolerancePX || vertex.X > generatedWidthPX-tolerancePX || vertex.Y > generatedHeightPX-tolerancePX {
+			return true
+		}
+	}
+	return false
+}
+
+// getGeneratedTerritoryID names territories A to Z then AA, AB and so on
+func getGeneratedTerritoryID(index int) string {
+	id := ""
+	for index >= 0 {
+		id = string(rune('A'+index%26)) + id
+		index = index/26 - 1
+	}
+	return id
+}
+
+var terrainColours = map[databasemap.Terrain]string{
+	databasemap.Plains:    "#7d8f4e",
+	databasemap.Forest:    "#3f5f36",
+	databasemap.Hills:     "#8a7650",
+	databasemap.Mountains: "#6b6660",
+	databasemap.Coast:     "#5f8a8b",
+	databasemap.Marsh:     "#546b5a",
+}
+
+// RenderSVG draws a layout's territory outlines coloured by terrain for layouts without a hand drawn background
+func RenderSVG(layout Map) string {
+	svg := strings.Builder{}
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, layout.WidthPX, layout.HeightPX, layout.WidthPX, layout.HeightPX)
+	svg.WriteString("\n")
+
+	for _, territory := range layout.Territories {
+		outline, hasOutline := layout.Outlines[territory.ID]
+		if !hasOutline {
+			continue
+		}
+
+		points := []string{}
+		for _, point := range outline {
+			points = append(points, fmt.Sprintf("%.1f,%.1f", point.X, point.Y))
+		}
+
+		fmt.Fprintf(&svg, `<polygon points="%s" fill="%s" stroke="#2b2418" stroke-width="3" stroke-linejoin="round" />`, strings.Join(points, " "), terrainColours[territory.Terrain])
+		svg.WriteString("\n")
+	}
+
+	svg.WriteString("</svg>\n")
+	return svg.String()
+}
@@ -0,0 +1,116 @@
+package strategicmap
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeneratedLayoutsAreValidForManySeeds(t *testing.T) {
+
+	for seed := int64(0); seed < 50; seed++ {
+		for _, territoryCount := range []int{MinimumGeneratedTerritories, DefaultGeneratedTerritories, MaximumGeneratedTerritories} {
+			layout, err := GenerateLayout(territoryCount, seed)
+			assert.NoError(t, err)
+			assert.NoError(t, FromDatabaseLayout(layout.ID, "map1", layout.ToDatabaseLayout()).Validate(), layout.ID)
+			assert.Len(t, layout.Territories, territoryCount)
+			assert.Len(t, layout.Outlines, territoryCount)
+		}
+	}
+}
+
+func TestTheSameSeedGeneratesTheSameLayout(t *testing.T) {
+
+	layout, err := GenerateLayout(DefaultGeneratedTerritories, 42)
+	assert.NoError(t, err)
+
+	sameLayout, err := GenerateLayout(DefaultGeneratedTerritories, 42)
+	assert.NoError(t, err)
+	assert.Equal(t, layout, sameLayout)
+
+	otherLayout, err := GenerateLayout(DefaultGeneratedTerritories, 43)
+	assert.NoError(t, err)
+	assert.NotEqual(t, layout.Territories, otherLayout.Territories)
+}
+
+func TestGeneratedLayoutsHaveReadableNamesAndArentShippedLayouts(t *testing.T) {
+
+	layout, err := GenerateLayout(12, -7)
+	assert.NoError(t, err)
+	assert.Equal(t, "generated-12--7", layout.ID)
+	assert.Equal(t, "Generated Board of 12 Territories", layout.Name)
+	assert.True(t, layout.IsGenerated())
+
+	_, doesLayoutExist := FindLayout([]Map{StaticMap}, layout.ID)
+	assert.False(t, doesLayoutExist)
+}
+
+func TestAGeneratedLayoutIsTheSameAfterBeingStored(t *testing.T) {
+
+	layout, err := GenerateLayout(DefaultGeneratedTerritories, 3)
+	assert.NoError(t, err)
+
+	storedLayout := FromDatabaseLayout(layout.ID, "map1", layout.ToDatabaseLayout())
+	assert.Equal(t, "/maps/map1/background.svg", storedLayout.BackgroundImage)
+
+	storedLayout.BackgroundImage = ""
+	assert.Equal(t, layout, storedLayout)
+}
+
+func TestGeneratingRejectsTerritoryCountsOutOfRange(t *testing.T) {
+
+	_, err := GenerateLayout(MinimumGeneratedTerritories-1, 0)
+	assert.Error(t, err)
+
+	_, err = GenerateLayout(MaximumGeneratedTerritories+1, 0)
+	assert.Error(t, err)
+}
+
+func TestGeneratedBordersAreSymmetricAndEveryTerritoryHasANeighbour(t *testing.T) {
+
+	layout, err := GenerateLayout(DefaultGeneratedTerritories, 5)
+	assert.NoError(t, err)
+
+	for _, territory := range layout.Territories {
+		neighbours := layout.Neighbours(territory.ID)
+		assert.NotEmpty(t, neighbours, territory.ID)
+		for _, neighbourID := range neighbours {
+			assert.True(t, layout.AreNeighbours(neighbourID, territory.ID))
+		}
+	}
+}
+
+func TestGeneratedTerritoryIDsFollowSpreadsheetColumns(t *testing.T) {
+
+	assert.Equal(t, "A", getGeneratedTerritoryID(0))
+	assert.Equal(t, "Z", getGeneratedTerritoryID(25))
+	assert.Equal(t, "AA", getGeneratedTerritoryID(26))
+	assert.Equal(t, "AN", getGeneratedTerritoryID(39))
+}
+
+func TestRenderingAGeneratedLayoutDrawsEachTerritory(t *testing.T) {
+
+	layout, err := GenerateLayout(MinimumGeneratedTerritories, 1)
+	assert.NoError(t, err)
+
+	svg := RenderSVG(layout)
+	assert.True(t, strings.HasPrefix(svg, "<svg"))
+	assert.Contains(t, svg, `width="1536" height="723"`)
+	assert.Equal(t, MinimumGeneratedTerritories, strings.Count(svg, "<polygon"))
+
+	assert.Equal(t, 0, strings.Count(RenderSVG(StaticMap), "<polygon"))
+}
+
+func TestLayoutFilesCantUseGeneratedIDs(t *testing.T) {
+
+	directory := t.TempDir()
+	writeLayoutFile(t, directory, "generated.json", `{
+		"ID": "generated-6-1", "Name": "Sneaky", "WidthPX": 100, "HeightPX": 50, "BackgroundImage": "/assets/images/map.jpg",
+		"Territories": [{"ID": "A", "LeftPX": 10, "TopPX": 10, "Terrain": "plains"}, {"ID": "B", "LeftPX": 50, "TopPX": 20, "Terrain": "hills"}],
+		"Borders": [{"A": "A", "B": "B"}]
+	}`)
+
+	_, err := LoadLayouts(directory, []Map{StaticMap})
+	assert.Error(t, err)
+}
@@ -0,0 +1,173 @@
+package strategicmap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// LoadLayout reads a layout from a JSON file and checks that it's playable
+func LoadLayout(path string) (Map, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Map{}, err
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+
+	layout := Map{}
+	err = decoder.Decode(&layout)
+	if err != nil {
+		return Map{}, fmt.Errorf("Layout file %s couldn't be read: %w", path, err)
+	}
+
+	err = layout.Validate()
+	if err != nil {
+		return Map{}, fmt.Errorf("Layout file %s is invalid: %w", path, err)
+	}
+
+	return layout, nil
+}
+
+// LoadLayouts returns the built in layouts followed by every layout in the directory, if it exists
+func LoadLayouts(directory string, builtInLayouts []Map) ([]Map, error) {
+	layouts := append([]Map{}, builtInLayouts...)
+
+	fileInfos, err := ioutil.ReadDir(directory)
+	if os.IsNotExist(err) {
+		return layouts, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fileNames := []string{}
+	for _, fileInfo := range fileInfos {
+		if !fileInfo.IsDir() && filepath.Ext(fileInfo.Name()) == ".json" {
+			fileNames = append(fileNames, fileInfo.Name())
+		}
+	}
+	sort.Strings(fileNames)
+
+	for _, fileName := range fileNames {
+		path := filepath.Join(directory, fileName)
+		layout, err := LoadLayout(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if layout.IsGenerated() {
+			return nil, fmt.Errorf("Layout file %s uses the ID %s but IDs starting with %s are kept for generated layouts", path, layout.ID, GeneratedLayoutIDPrefix)
+		}
+
+		_, isDuplicate := FindLayout(layouts, layout.ID)
+		if isDuplicate {
+			return nil, fmt.Errorf("Layout file %s uses the ID %s which is already taken", path, layout.ID)
+		}
+
+		layouts = append(layouts, layout)
+	}
+
+	return layouts, nil
+}
+
+func FindLayout(layouts []Map, id string) (Map, bool) {
+	for _, layout := range layouts {
+		if layout.ID == id {
+			return layout, true
+		}
+	}
+	return Map{}, false
+}
+
+func (strategicMap Map) Validate() error {
+	if len(strategicMap.ID) == 0 {
+		return errors.New("The layout needs an ID")
+	}
+
+	if len(strategicMap.Name) == 0 {
+		return fmt.Errorf("Layout %s needs a name", strategicMap.ID)
+	}
+
+	if strategicMap.WidthPX <= 0 || strategicMap.HeightPX <= 0 {
+		return fmt.Errorf("Layout %s needs a width and height above zero", strategicMap.ID)
+	}
+
+	if len(strategicMap.BackgroundImage) == 0 {
+		return fmt.Errorf("Layout %s needs a background image", strategicMap.ID)
+	}
+
+	if len(strategicMap.Territories) < 2 {
+		return fmt.Errorf("Layout %s needs at least two territories", strategicMap.ID)
+	}
+
+	territoryIDs := map[string]bool{}
+	for _, territory := range strategicMap.Territories {
+		if len(territory.ID) == 0 {
+			return fmt.Errorf("Layout %s has a territory without an ID", strategicMap.ID)
+		}
+
+		if territoryIDs[territory.ID] {
+			return fmt.Errorf("Layout %s has more than one territory %s", strategicMap.ID, territory.ID)
+		}
+		territoryIDs[territory.ID] = true
+
+		if territory.LeftPX < 0 || territory.LeftPX > strategicMap.WidthPX || territory.TopPX < 0 || territory.TopPX > strategicMap.HeightPX {
+			return fmt.Errorf("Territory %s is outside layout %s", territory.ID, strategicMap.ID)
+		}
+
+		if !territory.Terrain.IsValid() {
+			return fmt.Errorf("Territory %s in layout %s has unknown terrain %s", territory.ID, strategicMap.ID, territory.Terrain)
+		}
+	}
+
+	for outlineID := range strategicMap.Outlines {
+		if !territoryIDs[outlineID] {
+			return fmt.Errorf("Layout %s has an outline for %s which isn't a territory", strategicMap.ID, outlineID)
+		}
+	}
+
+	borders := map[Border]bool{}
+	for _, border := range strategicMap.Borders {
+		if !territoryIDs[border.A] || !territoryIDs[border.B] {
+			return fmt.Errorf("Layout %s has a border between %s and %s but one of them isn't a territory", strategicMap.ID, border.A, border.B)
+		}
+
+		if border.A == border.B {
+			return fmt.Errorf("Territory %s in layout %s can't border itself", border.A, strategicMap.ID)
+		}
+
+		if borders[border] || borders[Border{A: border.B, B: border.A}] {
+			return fmt.Errorf("Layout %s has the border between %s and %s more than once", strategicMap.ID, border.A, border.B)
+		}
+		borders[border] = true
+	}
+
+	// every territory has to be reachable or nations on an island could never be attacked
+	reached := map[string]bool{strategicMap.Territories[0].ID: true}
+	toVisit := []string{strategicMap.Territories[0].ID}
+	for len(toVisit) > 0 {
+		territoryID := toVisit[0]
+		toVisit = toVisit[1:]
+		for _, neighbourID := range strategicMap.Neighbours(territoryID) {
+			if !reached[neighbourID] {
+				reached[neighbourID] = true
+				toVisit = append(toVisit, neighbourID)
+			}
+		}
+	}
+
+	for _, territory := range strategicMap.Territories {
+		if !reached[territory.ID] {
+			return fmt.Errorf("Territory %s in layout %s can't be reached from the rest of the map", territory.ID, strategicMap.ID)
+		}
+	}
+
+	return nil
+}
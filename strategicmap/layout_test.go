@@ -0,0 +1,147 @@
+package strategicmap
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/brickman1444/NSImperialism/databasemap"
+	"github.com/stretchr/testify/assert"
+)
+
+func makeValidLayout() Map {
+	return Map{
+		ID:              "small",
+		Name:            "Small",
+		WidthPX:         100,
+		HeightPX:        50,
+		BackgroundImage: "/assets/images/map.jpg",
+		Territories: []Territory{
+			{"A", 10, 10, databasemap.Plains},
+			{"B", 50, 20, databasemap.Hills},
+			{"C", 90, 40, databasemap.Coast},
+		},
+		Borders: []Border{{"A", "B"}, {"B", "C"}},
+	}
+}
+
+func writeLayoutFile(t *testing.T, directory string, fileName string, contents string) {
+	err := ioutil.WriteFile(filepath.Join(directory, fileName), []byte(contents), 0644)
+	assert.NoError(t, err)
+}
+
+func TestTheBuiltInLayoutIsValid(t *testing.T) {
+
+	assert.NoError(t, StaticMap.Validate())
+}
+
+func TestInvalidLayoutsAreRejected(t *testing.T) {
+
+	assert.NoError(t, makeValidLayout().Validate())
+
+	invalidLayouts := map[string]func(*Map){
+		"missing ID":            func(layout *Map) { layout.ID = "" },
+		"zero width":            func(layout *Map) { layout.WidthPX = 0 },
+		"missing background":    func(layout *Map) { layout.BackgroundImage = "" },
+		"duplicate territory":   func(layout *Map) { layout.Territories[1].ID = "A" },
+		"territory off map":     func(layout *Map) { layout.Territories[2].LeftPX = 101 },
+		"unknown terrain":       func(layout *Map) { layout.Territories[0].Terrain = "lava" },
+		"border to nowhere":     func(layout *Map) { layout.Borders = append(layout.Borders, Border{"A", "Z"}) },
+		"border with itself":    func(layout *Map) { layout.Borders = append(layout.Borders, Border{"A", "A"}) },
+		"duplicate border":      func(layout *Map) { layout.Borders = append(layout.Borders, Border{"B", "A"}) },
+		"unreachable territory": func(layout *Map) { layout.Borders = layout.Borders[:1] },
+	}
+
+	for description, breakLayout := range invalidLayouts {
+		layout := makeValidLayout()
+		breakLayout(&layout)
+		assert.Error(t, layout.Validate(), description)
+	}
+}
+
+func TestLayoutsAreLoadedFromADirectoryAfterTheBuiltInOnes(t *testing.T) {
+
+	directory, err := ioutil.TempDir("", "layouts")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	writeLayoutFile(t, directory, "small.json", `{
+		"ID": "small", "Name": "Small", "WidthPX": 100, "HeightPX": 50, "BackgroundImage": "/assets/images/map.jpg",
+		"Territories": [{"ID": "A", "LeftPX": 10, "TopPX": 10, "Terrain": "plains"}, {"ID": "B", "LeftPX": 50, "TopPX": 20, "Terrain": "hills"}],
+		"Borders": [{"A": "A", "B": "B"}]
+	}`)
+	writeLayoutFile(t, directory, "notes.txt", "not a layout")
+
+	layouts, err := LoadLayouts(directory, []Map{StaticMap})
+	assert.NoError(t, err)
+	assert.Len(t, layouts, 2)
+	assert.Equal(t, DefaultLayoutID, layouts[0].ID)
+
+	small, doesLayoutExist := FindLayout(layouts, "small")
+	assert.True(t, doesLayoutExist)
+	assert.Equal(t, 50, small.HeightPX)
+	assert.Equal(t, databasemap.Hills, small.Territories[1].Terrain)
+	assert.True(t, small.AreNeighbours("A", "B"))
+
+	defaultLayout, doesLayoutExist := FindLayout(layouts, DefaultLayoutID)
+	assert.True(t, doesLayoutExist)
+	assert.Equal(t, DefaultLayoutID, defaultLayout.ID)
+
+	_, doesLayoutExist = FindLayout(layouts, "missing")
+	assert.False(t, doesLayoutExist)
+}
+
+func TestLoadingLayoutsFailsOnAnInvalidOrDuplicateFile(t *testing.T) {
+
+	directory, err := ioutil.TempDir("", "layouts")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	writeLayoutFile(t, directory, "classic.json", `{
+		"ID": "classic", "Name": "Another Classic", "WidthPX": 100, "HeightPX": 50, "BackgroundImage": "/assets/images/map.jpg",
+		"Territories": [{"ID": "A", "LeftPX": 10, "TopPX": 10, "Terrain": "plains"}, {"ID": "B", "LeftPX": 50, "TopPX": 20, "Terrain": "hills"}],
+		"Borders": [{"A": "A", "B": "B"}]
+	}`)
+
+	_, err = LoadLayouts(directory, []Map{StaticMap})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "classic.json")
+
+	writeLayoutFile(t, directory, "classic.json", `{"ID": "typo", "Territorys": []}`)
+
+	_, err = LoadLayouts(directory, []Map{StaticMap})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "classic.json")
+}
+
+func TestTheLayoutsShippedWithTheGameAreValid(t *testing.T) {
+
+	directory := filepath.Join("..", "layouts")
+	directoryInfo, err := os.Stat(directory)
+	assert.NoError(t, err)
+	assert.True(t, directoryInfo.IsDir())
+
+	layouts, err := LoadLayouts(directory, []Map{StaticMap})
+	assert.NoError(t, err)
+	assert.Greater(t, len(layouts), 1)
+	assert.Equal(t, DefaultLayoutID, layouts[0].ID)
+
+	for _, layout := range layouts {
+		_, err = os.Stat(filepath.Join("..", filepath.FromSlash(layout.BackgroundImage)))
+		assert.NoError(t, err, layout.ID)
+	}
+
+	layout, doesLayoutExist := FindLayout(layouts, "inland_sea")
+	assert.True(t, doesLayoutExist)
+	assert.NotEqual(t, StaticMap.BackgroundImage, layout.BackgroundImage)
+	assert.Len(t, layout.Territories, 11)
+}
+
+func TestNewMapsRememberTheirLayout(t *testing.T) {
+
+	databaseMap, err := MakeNewRandomMap(makeValidLayout(), []string{"nation1", "nation2"}, "name", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "small", databaseMap.LayoutID)
+	assert.Len(t, databaseMap.Cells, 3)
+}
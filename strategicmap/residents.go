@@ -5,33 +5,27 @@ import (
 	"math/rand"
 
 	"github.com/brickman1444/NSImperialism/databasemap"
-	"github.com/brickman1444/NSImperialism/dynamodbwrapper"
 	"github.com/google/uuid"
 )
 
-type MapsInterface interface {
-	GetMap(mapID string) (databasemap.DatabaseMap, error)
-	PutMap(databaseMap databasemap.DatabaseMap) error
+func MakeNewRandomMap(mapLayout Map, participatingNations []string, name string, seed int64) (databasemap.DatabaseMap, error) {
+	return MakeNewRandomMapWithNeutralTerritories(mapLayout, participatingNations, 0, name, seed)
 }
 
-type MapsDatabase struct {
-}
-
-func (mapsDatabase MapsDatabase) GetMap(mapID string) (databasemap.DatabaseMap, error) {
-	return dynamodbwrapper.GetMap(mapID)
-}
-
-func (mapsDatabase MapsDatabase) PutMap(databaseMap databasemap.DatabaseMap) error {
-	return dynamodbwrapper.PutMap(databaseMap)
-}
-
-var databaseInterfaceChecker MapsInterface = MapsDatabase{}
-
-func MakeNewRandomMap(mapLayout Map, participatingNations []string, name string) (databasemap.DatabaseMap, error) {
+// MakeNewRandomMapWithNeutralTerritories leaves some territories defended by a random neutral strength until a nation annexes them
+func MakeNewRandomMapWithNeutralTerritories(mapLayout Map, participatingNations []string, neutralTerritories int, name string, seed int64) (databasemap.DatabaseMap, error) {
 	databaseMap := databasemap.NewBlankDatabaseMap()
 
 	databaseMap.ID = uuid.NewString()
 	databaseMap.Name = name
+	databaseMap.Seed = seed
+	databaseMap.LayoutID = mapLayout.ID
+	if mapLayout.IsGenerated() {
+		databaseLayout := mapLayout.ToDatabaseLayout()
+		databaseMap.GeneratedLayout = &databaseLayout
+	}
+
+	random := rand.New(rand.NewSource(seed))
 
 	if len(participatingNations) < 2 {
 		return databaseMap, errors.New("Creating a map requires at least two nations")
@@ -41,24 +35,37 @@ func MakeNewRandomMap(mapLayout Map, participatingNations []string, name string)
 		return databaseMap, errors.New("There must be space for each nation to get at least one territory")
 	}
 
-	residentsForEachCell := make([]string, len(participatingNations), len(mapLayout.Territories))
-	copy(residentsForEachCell, participatingNations) // this gives each nation one cell
+	if neutralTerritories < 0 || len(participatingNations)+neutralTerritories > len(mapLayout.Territories) {
+		return databaseMap, errors.New("There must be space for each nation to get at least one territory after the neutral territories")
+	}
+
+	residentsForEachCell := make([]string, len(participatingNations)+neutralTerritories, len(mapLayout.Territories))
+	copy(residentsForEachCell, participatingNations) // this gives each nation one cell and leaves the neutral cells empty
 
 	for len(residentsForEachCell) < len(mapLayout.Territories) {
 
-		randomNationIndex := rand.Intn(len(participatingNations))
+		randomNationIndex := random.Intn(len(participatingNations))
 		residentsForEachCell = append(residentsForEachCell, participatingNations[randomNationIndex])
 	}
 
-	rand.Shuffle(len(residentsForEachCell), func(i, j int) {
+	random.Shuffle(len(residentsForEachCell), func(i, j int) {
 		residentsForEachCell[i], residentsForEachCell[j] = residentsForEachCell[j], residentsForEachCell[i]
 	})
 
 	for territoryIndex, _ := range mapLayout.Territories {
 		territoryID := mapLayout.Territories[territoryIndex].ID
 		databaseMap.Cells[territoryID] = databasemap.DatabaseCell{
-			ID:       territoryID,
-			Resident: residentsForEachCell[territoryIndex],
+			ID:      territoryID,
+			Terrain: mapLayout.Territories[territoryIndex].Terrain,
+			Income:  databasemap.TerrainIncome(mapLayout.Territories[territoryIndex].Terrain),
+		}
+
+		if len(residentsForEachCell[territoryIndex]) == 0 {
+			territory := databaseMap.Cells[territoryID]
+			territory.NeutralStrength = databasemap.MinimumNeutralStrength + random.Intn(databasemap.MaximumNeutralStrength-databasemap.MinimumNeutralStrength+1)
+			databaseMap.Cells[territoryID] = territory
+		} else {
+			databaseMap.SetResident(territoryID, residentsForEachCell[territoryIndex])
 		}
 	}
 
@@ -3,19 +3,20 @@ package strategicmap
 import (
 	"testing"
 
+	"github.com/brickman1444/NSImperialism/databasemap"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestRandomMapHasEveryCellFilled(t *testing.T) {
 
 	staticMap := Map{Territories: []Territory{
-		{"A", 0, 0},
-		{"B", 0, 0},
-		{"C", 0, 0},
+		{"A", 0, 0, databasemap.Plains},
+		{"B", 0, 0, databasemap.Plains},
+		{"C", 0, 0, databasemap.Plains},
 	}}
 
 	for simulationIndex := 0; simulationIndex < 1000; simulationIndex++ {
-		randomMap, err := MakeNewRandomMap(staticMap, []string{"nation1", "nation2"}, "map name")
+		randomMap, err := MakeNewRandomMap(staticMap, []string{"nation1", "nation2"}, "map name", int64(simulationIndex))
 		assert.NoError(t, err)
 
 		assert.NotEmpty(t, randomMap.Cells["A"].Resident)
@@ -27,13 +28,13 @@ func TestRandomMapHasEveryCellFilled(t *testing.T) {
 func TestRandomMapEachNationHasAtLeastOneCell(t *testing.T) {
 
 	staticMap := Map{Territories: []Territory{
-		{"A", 0, 0},
-		{"B", 0, 0},
-		{"C", 0, 0},
+		{"A", 0, 0, databasemap.Plains},
+		{"B", 0, 0, databasemap.Plains},
+		{"C", 0, 0, databasemap.Plains},
 	}}
 
 	for simulationIndex := 0; simulationIndex < 1000; simulationIndex++ {
-		randomMap, err := MakeNewRandomMap(staticMap, []string{"nation1", "nation2"}, "map name")
+		randomMap, err := MakeNewRandomMap(staticMap, []string{"nation1", "nation2"}, "map name", int64(simulationIndex))
 		assert.NoError(t, err)
 
 		numberOfNation1Cells := 0
@@ -60,13 +61,13 @@ func TestRandomMapEachNationHasAtLeastOneCell(t *testing.T) {
 func TestCreatingAMapWithLessThanTwoNationsIsAnError(t *testing.T) {
 
 	staticMap := Map{Territories: []Territory{
-		{"A", 0, 0},
-		{"B", 0, 0},
-		{"C", 0, 0},
+		{"A", 0, 0, databasemap.Plains},
+		{"B", 0, 0, databasemap.Plains},
+		{"C", 0, 0, databasemap.Plains},
 	}}
 
 	for simulationIndex := 0; simulationIndex < 1000; simulationIndex++ {
-		_, err := MakeNewRandomMap(staticMap, []string{"nation1"}, "map name")
+		_, err := MakeNewRandomMap(staticMap, []string{"nation1"}, "map name", int64(simulationIndex))
 		assert.Error(t, err)
 	}
 }
@@ -74,13 +75,13 @@ func TestCreatingAMapWithLessThanTwoNationsIsAnError(t *testing.T) {
 func TestCreatingAMapMoreNationsThanCellsIsAnError(t *testing.T) {
 
 	staticMap := Map{Territories: []Territory{
-		{"A", 0, 0},
-		{"B", 0, 0},
-		{"C", 0, 0},
+		{"A", 0, 0, databasemap.Plains},
+		{"B", 0, 0, databasemap.Plains},
+		{"C", 0, 0, databasemap.Plains},
 	}}
 
 	for simulationIndex := 0; simulationIndex < 1000; simulationIndex++ {
-		_, err := MakeNewRandomMap(staticMap, []string{"nation1", "nation2", "nation3", "nation4"}, "map name")
+		_, err := MakeNewRandomMap(staticMap, []string{"nation1", "nation2", "nation3", "nation4"}, "map name", int64(simulationIndex))
 		assert.Error(t, err)
 	}
 }
@@ -88,12 +89,83 @@ func TestCreatingAMapMoreNationsThanCellsIsAnError(t *testing.T) {
 func TestRandomMapHasNonEmptyID(t *testing.T) {
 
 	staticMap := Map{Territories: []Territory{
-		{"A", 0, 0},
-		{"B", 0, 0},
-		{"C", 0, 0},
+		{"A", 0, 0, databasemap.Plains},
+		{"B", 0, 0, databasemap.Plains},
+		{"C", 0, 0, databasemap.Plains},
 	}}
 
-	databaseMap, err := MakeNewRandomMap(staticMap, []string{"nation1", "nation2"}, "map name")
+	databaseMap, err := MakeNewRandomMap(staticMap, []string{"nation1", "nation2"}, "map name", 0)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, databaseMap.ID)
 }
+
+func TestRandomMapWithTheSameSeedIsTheSame(t *testing.T) {
+
+	staticMap := Map{Territories: []Territory{
+		{"A", 0, 0, databasemap.Plains},
+		{"B", 0, 0, databasemap.Plains},
+		{"C", 0, 0, databasemap.Plains},
+		{"D", 0, 0, databasemap.Plains},
+	}}
+
+	firstMap, err := MakeNewRandomMap(staticMap, []string{"nation1", "nation2", "nation3"}, "map name", 1234)
+	assert.NoError(t, err)
+
+	secondMap, err := MakeNewRandomMap(staticMap, []string{"nation1", "nation2", "nation3"}, "map name", 1234)
+	assert.NoError(t, err)
+
+	assert.Equal(t, int64(1234), firstMap.Seed)
+	assert.Equal(t, firstMap.Cells, secondMap.Cells)
+}
+
+func TestRandomMapCellsHaveTheLayoutsTerrain(t *testing.T) {
+
+	layout := Map{Territories: []Territory{
+		{"A", 0, 0, databasemap.Mountains},
+		{"B", 0, 0, databasemap.Coast},
+	}}
+
+	randomMap, err := MakeNewRandomMap(layout, []string{"nation1", "nation2"}, "map name", 1)
+	assert.NoError(t, err)
+
+	assert.Equal(t, databasemap.Mountains, randomMap.Cells["A"].Terrain)
+	assert.Equal(t, databasemap.Coast, randomMap.Cells["B"].Terrain)
+}
+
+func TestRandomMapLeavesNeutralTerritoriesWithTheirOwnStrength(t *testing.T) {
+
+	staticMap := Map{Territories: []Territory{
+		{"A", 0, 0, databasemap.Plains},
+		{"B", 0, 0, databasemap.Plains},
+		{"C", 0, 0, databasemap.Plains},
+		{"D", 0, 0, databasemap.Plains},
+	}}
+
+	for simulationIndex := 0; simulationIndex < 100; simulationIndex++ {
+		randomMap, err := MakeNewRandomMapWithNeutralTerritories(staticMap, []string{"nation1", "nation2"}, 2, "map name", int64(simulationIndex))
+		assert.NoError(t, err)
+
+		neutralTerritories := 0
+		for _, territory := range randomMap.Cells {
+			if territory.IsNeutral() {
+				neutralTerritories++
+				assert.GreaterOrEqual(t, territory.NeutralStrength, databasemap.MinimumNeutralStrength)
+				assert.LessOrEqual(t, territory.NeutralStrength, databasemap.MaximumNeutralStrength)
+			}
+		}
+		assert.Equal(t, 2, neutralTerritories)
+		assert.Len(t, randomMap.GetPlayers(), 2)
+	}
+}
+
+func TestRandomMapNeedsSpaceForEveryNationAfterTheNeutralTerritories(t *testing.T) {
+
+	staticMap := Map{Territories: []Territory{
+		{"A", 0, 0, databasemap.Plains},
+		{"B", 0, 0, databasemap.Plains},
+		{"C", 0, 0, databasemap.Plains},
+	}}
+
+	_, err := MakeNewRandomMapWithNeutralTerritories(staticMap, []string{"nation1", "nation2"}, 2, "map name", 1)
+	assert.Error(t, err)
+}
@@ -11,13 +11,28 @@ import (
 )
 
 type Territory struct {
-	ID     string
-	LeftPX int
-	TopPX  int
+	ID      string
+	LeftPX  int
+	TopPX   int
+	Terrain databasemap.Terrain
 }
 
+type Border struct {
+	A string
+	B string
+}
+
+// Map is a layout that games are played on
 type Map struct {
-	Territories []Territory
+	ID              string
+	Name            string
+	WidthPX         int // The size of the background image that territory positions are measured against
+	HeightPX        int
+	BackgroundImage string
+	OverlayImage    string // Drawn faintly over the background. Optional.
+	Territories     []Territory
+	Borders         []Border
+	Outlines        map[string][]Point // The shape of each territory by ID, only for layouts drawn with RenderSVG
 }
 
 type RenderedTerritory struct {
@@ -28,52 +43,82 @@ type RenderedTerritory struct {
 }
 
 type RenderedMap struct {
-	Territories []RenderedTerritory
-	Name        string
+	Territories     []RenderedTerritory
+	Name            string
+	BackgroundImage string
+	OverlayImage    string
 }
 
-const MAPWIDTHPX = 1536
-const MAPHEIGHTPX = 723
-
-var StaticMap = Map{Territories: []Territory{
-	{"A", 415, 95},
-	{"B", 580, 40},
-	{"C", 705, 100},
-	{"D", 815, 55},
-	{"E", 865, 145},
-	{"F", 985, 115},
-	{"G", 1100, 130},
-	{"H", 1170, 60},
-	{"I", 470, 240},
-	{"J", 650, 190},
-	{"K", 780, 255},
-	{"L", 1020, 270},
-	{"M", 625, 335},
-	{"N", 800, 450},
-	{"O", 970, 445},
-	{"P", 560, 490},
-	{"Q", 580, 630},
-	{"R", 840, 645},
+const DefaultLayoutID = "classic"
+
+// StaticMap is the built in layout
+var StaticMap = Map{ID: DefaultLayoutID, Name: "Classic", WidthPX: 1536, HeightPX: 723, BackgroundImage: "/assets/images/map.jpg", OverlayImage: "/assets/images/map_political.png", Territories: []Territory{
+	{"A", 415, 95, databasemap.Coast},
+	{"B", 580, 40, databasemap.Coast},
+	{"C", 705, 100, databasemap.Hills},
+	{"D", 815, 55, databasemap.Mountains},
+	{"E", 865, 145, databasemap.Forest},
+	{"F", 985, 115, databasemap.Plains},
+	{"G", 1100, 130, databasemap.Hills},
+	{"H", 1170, 60, databasemap.Mountains},
+	{"I", 470, 240, databasemap.Coast},
+	{"J", 650, 190, databasemap.Plains},
+	{"K", 780, 255, databasemap.Forest},
+	{"L", 1020, 270, databasemap.Marsh},
+	{"M", 625, 335, databasemap.Plains},
+	{"N", 800, 450, databasemap.Mountains},
+	{"O", 970, 445, databasemap.Forest},
+	{"P", 560, 490, databasemap.Coast},
+	{"Q", 580, 630, databasemap.Coast},
+	{"R", 840, 645, databasemap.Hills},
+}, Borders: []Border{
+	{"A", "B"},
+	{"A", "I"},
+	{"B", "C"},
+	{"C", "D"},
+	{"C", "J"},
+	{"C", "K"},
+	{"D", "E"},
+	{"E", "F"},
+	{"E", "K"},
+	{"F", "G"},
+	{"F", "L"},
+	{"G", "H"},
+	{"G", "L"},
+	{"I", "J"},
+	{"I", "M"},
+	{"J", "K"},
+	{"J", "M"},
+	{"K", "L"},
+	{"K", "M"},
+	{"K", "N"},
+	{"L", "N"},
+	{"L", "O"},
+	{"M", "N"},
+	{"M", "P"},
+	{"N", "O"},
+	{"N", "P"},
+	{"N", "Q"},
+	{"N", "R"},
+	{"O", "R"},
+	{"P", "Q"},
+	{"Q", "R"},
 }}
 
 func divideAndRoundToNearestInteger(numerator int, denominator int) int {
 	return int(math.Round(float64(numerator) / float64(denominator) * 100))
 }
 
-func (territory Territory) LeftPercent() int {
-	return divideAndRoundToNearestInteger(territory.LeftPX, MAPWIDTHPX)
+func (strategicMap Map) LeftPercent(territory Territory) int {
+	return divideAndRoundToNearestInteger(territory.LeftPX, strategicMap.WidthPX)
 }
 
-func (territory Territory) TopPercent() int {
-	return divideAndRoundToNearestInteger(territory.TopPX, MAPHEIGHTPX)
+func (strategicMap Map) TopPercent(territory Territory) int {
+	return divideAndRoundToNearestInteger(territory.TopPX, strategicMap.HeightPX)
 }
 
 func GetTerritoryDisplayName(territory databasemap.DatabaseCell) string {
-	if len(territory.Name) != 0 {
-		return territory.Name
-	} else {
-		return territory.ID
-	}
+	return territory.DisplayName()
 }
 
 func getTerritoryDisplayNameLink(territory databasemap.DatabaseCell, mapID string) string {
@@ -92,6 +137,10 @@ func getTextForTerritory(territoryDefinition Territory, databaseMap databasemap.
 
 	territoryDisplayNameLink := getTerritoryDisplayNameLink(territory, databaseMap.ID)
 
+	if territory.IsNeutral() {
+		return territoryDisplayNameLink + " 🏳️", nil
+	}
+
 	if territory.Resident == "" {
 		return territoryDisplayNameLink + " ❓", nil
 	}
@@ -101,9 +150,18 @@ func getTextForTerritory(territoryDefinition Territory, databaseMap databasemap.
 		return "", err
 	}
 
+	armyText := ""
+	regiments := 0
+	for _, army := range databaseMap.GetArmiesAt(territoryDefinition.ID) {
+		regiments += army.Regiments
+	}
+	if regiments != 0 {
+		armyText = fmt.Sprintf(" 🛡️%d", regiments)
+	}
+
 	war := war.FindOngoingWarAt(databaseMap.GetWars(), territoryDefinition.ID)
 	if war == nil {
-		return territoryDisplayNameLink + " " + string(residentNation.FlagThumbnail()), nil
+		return territoryDisplayNameLink + " " + string(residentNation.FlagThumbnail()) + armyText, nil
 	}
 
 	attacker, err := nationStatesProvider.GetNationData(war.Attacker)
@@ -111,12 +169,14 @@ func getTextForTerritory(territoryDefinition Territory, databaseMap databasemap.
 		return "", err
 	}
 
-	return fmt.Sprint(territoryDisplayNameLink, " ", residentNation.FlagThumbnail(), "⚔️", attacker.FlagThumbnail()), nil
+	return fmt.Sprint(territoryDisplayNameLink, " ", residentNation.FlagThumbnail(), "⚔️", attacker.FlagThumbnail(), armyText), nil
 }
 
 func Render(strategicMap Map, databaseMap databasemap.DatabaseMap, nationStatesProvider nationstates_api.NationStatesProvider) (RenderedMap, error) {
 	renderedMap := RenderedMap{}
 	renderedMap.Name = databasemap.GetDisplayName(databaseMap)
+	renderedMap.BackgroundImage = strategicMap.BackgroundImage
+	renderedMap.OverlayImage = strategicMap.OverlayImage
 
 	for _, territoryDefinition := range strategicMap.Territories {
 
@@ -126,8 +186,8 @@ func Render(strategicMap Map, databaseMap databasemap.DatabaseMap, nationStatesP
 		}
 
 		renderedTerritory := RenderedTerritory{
-			LeftPercent: territoryDefinition.LeftPercent(),
-			TopPercent:  territoryDefinition.TopPercent(),
+			LeftPercent: strategicMap.LeftPercent(territoryDefinition),
+			TopPercent:  strategicMap.TopPercent(territoryDefinition),
 			Text:        template.HTML(text),
 			ID:          territoryDefinition.ID,
 		}
@@ -146,3 +206,24 @@ func DoesTerritoryExist(strategicMap Map, territoryID string) bool {
 	}
 	return false
 }
+
+func (strategicMap Map) Neighbours(territoryID string) []string {
+	neighbours := []string{}
+	for _, border := range strategicMap.Borders {
+		if border.A == territoryID {
+			neighbours = append(neighbours, border.B)
+		} else if border.B == territoryID {
+			neighbours = append(neighbours, border.A)
+		}
+	}
+	return neighbours
+}
+
+func (strategicMap Map) AreNeighbours(territoryA string, territoryB string) bool {
+	for _, neighbour := range strategicMap.Neighbours(territoryA) {
+		if neighbour == territoryB {
+			return true
+		}
+	}
+	return false
+}
@@ -3,6 +3,7 @@ package strategicmap
 import (
 	"testing"
 
+	"github.com/brickman1444/NSImperialism/databasemap"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -12,9 +13,12 @@ func TestTerritoryLeftAsPercentDividesAndRoundsToInteger(t *testing.T) {
 	territoryB := Territory{LeftPX: 1020}
 	territoryC := Territory{LeftPX: 840}
 
-	assert.Equal(t, 27, territoryA.LeftPercent())
-	assert.Equal(t, 66, territoryB.LeftPercent())
-	assert.Equal(t, 55, territoryC.LeftPercent())
+	assert.Equal(t, 27, StaticMap.LeftPercent(territoryA))
+	assert.Equal(t, 66, StaticMap.LeftPercent(territoryB))
+	assert.Equal(t, 55, StaticMap.LeftPercent(territoryC))
+
+	smallerMap := Map{WidthPX: 2000}
+	assert.Equal(t, 21, smallerMap.LeftPercent(territoryA))
 }
 
 func TestTerritoryTopAsPercentDividesAndRoundsToInteger(t *testing.T) {
@@ -23,7 +27,46 @@ func TestTerritoryTopAsPercentDividesAndRoundsToInteger(t *testing.T) {
 	territoryB := Territory{TopPX: 270}
 	territoryC := Territory{TopPX: 645}
 
-	assert.Equal(t, 13, territoryA.TopPercent())
-	assert.Equal(t, 37, territoryB.TopPercent())
-	assert.Equal(t, 89, territoryC.TopPercent())
+	assert.Equal(t, 13, StaticMap.TopPercent(territoryA))
+	assert.Equal(t, 37, StaticMap.TopPercent(territoryB))
+	assert.Equal(t, 89, StaticMap.TopPercent(territoryC))
+
+	tallerMap := Map{HeightPX: 1000}
+	assert.Equal(t, 65, tallerMap.TopPercent(territoryC))
+}
+
+func TestNeighboursAreFoundFromEitherSideOfABorder(t *testing.T) {
+
+	strategicMap := Map{
+		Territories: []Territory{{"A", 0, 0, databasemap.Plains}, {"B", 0, 0, databasemap.Plains}, {"C", 0, 0, databasemap.Plains}},
+		Borders:     []Border{{"A", "B"}, {"B", "C"}},
+	}
+
+	assert.ElementsMatch(t, []string{"B"}, strategicMap.Neighbours("A"))
+	assert.ElementsMatch(t, []string{"A", "C"}, strategicMap.Neighbours("B"))
+	assert.ElementsMatch(t, []string{"B"}, strategicMap.Neighbours("C"))
+}
+
+func TestTerritoryWithNoBordersHasNoNeighbours(t *testing.T) {
+
+	strategicMap := Map{Territories: []Territory{{"A", 0, 0, databasemap.Plains}}}
+
+	assert.Empty(t, strategicMap.Neighbours("A"))
+	assert.False(t, strategicMap.AreNeighbours("A", "B"))
+}
+
+func TestStaticMapBordersReferToRealTerritories(t *testing.T) {
+
+	for _, border := range StaticMap.Borders {
+		assert.True(t, DoesTerritoryExist(StaticMap, border.A), border.A)
+		assert.True(t, DoesTerritoryExist(StaticMap, border.B), border.B)
+		assert.NotEqual(t, border.A, border.B)
+	}
+}
+
+func TestEveryStaticMapTerritoryHasANeighbour(t *testing.T) {
+
+	for _, territory := range StaticMap.Territories {
+		assert.NotEmpty(t, StaticMap.Neighbours(territory.ID), territory.ID)
+	}
 }
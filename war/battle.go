@@ -0,0 +1,294 @@
+package war
+
+import (
+	"math/rand"
+
+	"github.com/brickman1444/NSImperialism/databasemap"
+	"github.com/brickman1444/NSImperialism/nationstates_api"
+)
+
+const decisiveScore = 100
+
+// ResolveBattles fights a battle in every territory with armies of a nation at war with its resident and ends the wars the warscore has decided
+func ResolveBattles(databaseMap *databasemap.DatabaseMap, nationStatesProvider nationstates_api.NationStatesProvider, random *rand.Rand) error {
+	battlefield := NewBattlefield(*databaseMap)
+
+	for _, territoryID := range databaseMap.GetCellIDs() {
+		err := resolveBattleAt(databaseMap, territoryID, nationStatesProvider, battlefield, random)
+		if err != nil {
+			return err
+		}
+	}
+
+	wearDownUnfoughtWars(databaseMap)
+
+	return endDecidedWars(databaseMap)
+}
+
+// wearDownUnfoughtWars swings the warscore towards the defender of every war without a battle this year since holding out is enough to win
+func wearDownUnfoughtWars(databaseMap *databasemap.DatabaseMap) {
+	for _, databaseWar := range databaseMap.GetWars() {
+		if !databaseWar.IsOngoing || databaseWar.IsInCeasefire(databaseMap.Year) || wasFoughtIn(databaseWar, databaseMap.Year) {
+			continue
+		}
+
+		databaseWar.Score = clampScore(databaseWar.Score - scoreDeltaPerYear(databaseWar.Occasion)*(databaseMap.Year-databaseWar.StartYear))
+		databaseMap.Wars[databaseWar.ID] = databaseWar
+	}
+}
+
+func wasFoughtIn(databaseWar databasemap.DatabaseWar, year int) bool {
+	for _, battle := range databaseWar.Battles {
+		if battle.Year == year {
+			return true
+		}
+	}
+	return false
+}
+
+// endDecidedWars hands the territory to the attackers of a war whose warscore reached them and ends a war whose warscore reached the defenders
+func endDecidedWars(databaseMap *databasemap.DatabaseMap) error {
+	for _, databaseWar := range databaseMap.GetWars() {
+		if !databaseWar.IsOngoing || Abs(databaseWar.Score) < decisiveScore {
+			continue
+		}
+
+		if databaseWar.Score > 0 {
+			err := occupy(databaseMap, databaseWar.TerritoryName, databaseWar.GetAttackers(), databaseWar)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		databaseWar.IsOngoing = false
+		databaseWar.Outcome = databasemap.OutcomeVictory
+		databaseWar.Victor = databaseWar.Defender
+		databaseMap.Wars[databaseWar.ID] = databaseWar
+		databaseMap.ClearPeaceOffer(databaseWar.ID)
+	}
+
+	return nil
+}
+
+func resolveBattleAt(databaseMap *databasemap.DatabaseMap, territoryID string, nationStatesProvider nationstates_api.NationStatesProvider, battlefield Battlefield, random *rand.Rand) error {
+	resident := databaseMap.Cells[territoryID].Resident
+	if len(resident) == 0 {
+		return nil
+	}
+
+	armies := databaseMap.GetArmiesAt(territoryID)
+
+	invaders := []string{}
+	for _, army := range armies {
+		if databaseMap.AreAtWar(army.Owner, resident) && !containsString(invaders, army.Owner) {
+			invaders = append(invaders, army.Owner)
+		}
+	}
+
+	if len(invaders) == 0 {
+		return nil
+	}
+
+	defenders := []string{resident}
+	for _, army := range armies {
+		if !containsString(invaders, army.Owner) && !containsString(defenders, army.Owner) && isAtWarWithAny(*databaseMap, army.Owner, invaders) {
+			defenders = append(defenders, army.Owner)
+		}
+	}
+
+	theWar, isThereAWar := findWarForBattle(*databaseMap, territoryID, invaders, resident)
+	if !isThereAWar {
+		return nil
+	}
+
+	invaderForces, err := coalitionForces(invaders, territoryID, nationStatesProvider, battlefield)
+	if err != nil {
+		return err
+	}
+
+	defenderForces, err := coalitionForces(defenders, territoryID, nationStatesProvider, battlefield)
+	if err != nil {
+		return err
+	}
+
+	// Each piece of gold spent funding the war buys one more unit of forces for the next battle
+	invadersAreAttackers := containsString(theWar.GetDefenders(), resident)
+	if invadersAreAttackers {
+		invaderForces += theWar.AttackerFunding
+		defenderForces += theWar.DefenderFunding
+	} else {
+		invaderForces += theWar.DefenderFunding
+		defenderForces += theWar.AttackerFunding
+	}
+	theWar.AttackerFunding = 0
+	theWar.DefenderFunding = 0
+
+	defenderForces = defenderForces * (100 + battlefield.DefenseBonusPercent(territoryID)) / 100
+
+	randomRoll := random.Intn(defenderForces + invaderForces)
+	invadersWon := randomRoll >= defenderForces
+
+	losers := defenders
+	if !invadersWon {
+		losers = invaders
+	}
+	regimentsLost := removeHalfOfTheRegiments(databaseMap, territoryID, losers)
+
+	battle := databasemap.DatabaseBattle{
+		Year:           databaseMap.Year,
+		TerritoryID:    territoryID,
+		AttackerForces: invaderForces,
+		DefenderForces: defenderForces,
+		Roll:           randomRoll,
+		RegimentsLost:  regimentsLost,
+	}
+	if !invadersAreAttackers {
+		battle.AttackerForces, battle.DefenderForces = defenderForces, invaderForces
+	}
+
+	scoreDelta := scoreDeltaPerYear(theWar.Occasion) * (databaseMap.Year - theWar.StartYear)
+	if invadersWon == invadersAreAttackers {
+		battle.Winner = theWar.Attacker
+		battle.ScoreDelta = scoreDelta
+	} else {
+		battle.Winner = theWar.Defender
+		battle.ScoreDelta = -scoreDelta
+	}
+
+	theWar.Score = clampScore(theWar.Score + battle.ScoreDelta)
+	theWar.Battles = append(theWar.Battles, battle)
+	databaseMap.Wars[theWar.ID] = theWar
+
+	if invadersWon && databaseMap.RegimentsAt(territoryID, defenders) == 0 {
+		return occupy(databaseMap, territoryID, invaders, theWar)
+	}
+
+	return nil
+}
+
+// findWarForBattle prefers the war over the territory itself and skips wars in a ceasefire
+func findWarForBattle(databaseMap databasemap.DatabaseMap, territoryID string, invaders []string, resident string) (databasemap.DatabaseWar, bool) {
+	wars := []databasemap.DatabaseWar{}
+	for _, databaseWar := range databaseMap.GetWars() {
+		if !databaseWar.IsOngoing || databaseWar.IsInCeasefire(databaseMap.Year) {
+			continue
+		}
+
+		residentIsDefending := containsString(databaseWar.GetDefenders(), resident) && containsAnyString(databaseWar.GetAttackers(), invaders)
+		residentIsAttacking := containsString(databaseWar.GetAttackers(), resident) && containsAnyString(databaseWar.GetDefenders(), invaders)
+		if residentIsDefending || residentIsAttacking {
+			wars = append(wars, databaseWar)
+		}
+	}
+
+	for _, databaseWar := range wars {
+		if databaseWar.TerritoryName == territoryID {
+			return databaseWar, true
+		}
+	}
+
+	if len(wars) == 0 {
+		return databasemap.DatabaseWar{}, false
+	}
+	return wars[0], true
+}
+
+// occupy hands the territory to the invaders and ends the wars fought over it
+func occupy(databaseMap *databasemap.DatabaseMap, territoryID string, invaders []string, theWar databasemap.DatabaseWar) error {
+	conqueror := invaders[0]
+	mostRegiments := 0
+	for _, invader := range invaders {
+		regiments := databaseMap.RegimentsAt(territoryID, []string{invader})
+		if regiments > mostRegiments {
+			conqueror = invader
+			mostRegiments = regiments
+		}
+	}
+
+	if theWar.TerritoryName == territoryID && containsString(theWar.GetAttackers(), conqueror) {
+		conqueror = theWar.Claimant()
+	}
+
+	err := databaseMap.SetResident(territoryID, conqueror)
+	if err != nil {
+		return err
+	}
+
+	for _, databaseWar := range databaseMap.GetWars() {
+		if !databaseWar.IsOngoing || databaseWar.TerritoryName != territoryID {
+			continue
+		}
+
+		databaseWar.IsOngoing = false
+		if conqueror == databaseWar.Claimant() || containsString(databaseWar.GetAttackers(), conqueror) {
+			databaseWar.Outcome = databasemap.OutcomeVictory
+			databaseWar.Victor = databaseWar.Attacker
+		} else {
+			databaseWar.Outcome = databasemap.OutcomeWhitePeace // Someone else took the territory the war was fought over
+		}
+
+		databaseMap.Wars[databaseWar.ID] = databaseWar
+		databaseMap.ClearPeaceOffer(databaseWar.ID)
+	}
+
+	return nil
+}
+
+// removeHalfOfTheRegiments is what losing a battle costs each of the losing nations' armies there, rounded up so a single regiment is wiped out
+func removeHalfOfTheRegiments(databaseMap *databasemap.DatabaseMap, territoryID string, nationIDs []string) int {
+	regimentsLost := 0
+	for _, army := range databaseMap.GetArmiesAt(territoryID) {
+		if !containsString(nationIDs, army.Owner) {
+			continue
+		}
+
+		lost := (army.Regiments + 1) / 2
+		army.Regiments -= lost
+		regimentsLost += lost
+
+		if army.Regiments == 0 {
+			delete(databaseMap.Armies, army.ID)
+		} else {
+			databaseMap.Armies[army.ID] = army
+		}
+	}
+	return regimentsLost
+}
+
+func isAtWarWithAny(databaseMap databasemap.DatabaseMap, nationID string, otherNationIDs []string) bool {
+	for _, otherNationID := range otherNationIDs {
+		if databaseMap.AreAtWar(nationID, otherNationID) {
+			return true
+		}
+	}
+	return false
+}
+
+func clampScore(score int) int {
+	if score > decisiveScore {
+		return decisiveScore
+	}
+	if score < -decisiveScore {
+		return -decisiveScore
+	}
+	return score
+}
+
+func containsAnyString(list []string, valuesToLookFor []string) bool {
+	for _, valueToLookFor := range valuesToLookFor {
+		if containsString(list, valueToLookFor) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, valueToLookFor string) bool {
+	for _, value := range list {
+		if value == valueToLookFor {
+			return true
+		}
+	}
+	return false
+}
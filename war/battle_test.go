@@ -0,0 +1,272 @@
+package war
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/brickman1444/NSImperialism/databasemap"
+	"github.com/brickman1444/NSImperialism/nationstates_api"
+	"github.com/stretchr/testify/assert"
+)
+
+func makeBattleTestNations(defenseForces map[string]int) nationstates_api.NationStatesProviderSimpleMap {
+	nationStatesProvider := nationstates_api.NewNationStatesProviderSimpleMap()
+	for nationID, nationDefenseForces := range defenseForces {
+		nation := nationstates_api.Nation{Id: nationID}
+		nation.SetDefenseForces(nationDefenseForces)
+		nationStatesProvider.PutNationData(nation)
+	}
+	return nationStatesProvider
+}
+
+// makeBattleTestMap has the attacker at war over B with an army of the given size already there
+func makeBattleTestMap(attackerRegiments int) databasemap.DatabaseMap {
+	databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A", "B", "C"})
+	databaseMap.Year = 1
+	databaseMap.SetResident("A", "attacker")
+	databaseMap.SetResident("B", "defender")
+	databaseMap.PutWars([]databasemap.DatabaseWar{databasemap.NewWar("attacker", "defender", "warForB", "B", 0)})
+	databaseMap.Armies["1"] = databasemap.DatabaseArmy{ID: "1", Owner: "attacker", TerritoryID: "B", Regiments: attackerRegiments}
+	return databaseMap
+}
+
+func TestAnArmyFightsTheResidentOfATerritoryItsOwnerIsAtWarWith(t *testing.T) {
+
+	nationStatesProvider := makeBattleTestNations(map[string]int{"attacker": 50, "defender": 50})
+	databaseMap := makeBattleTestMap(2)
+
+	assert.NoError(t, ResolveBattles(&databaseMap, nationStatesProvider, rand.New(rand.NewSource(1))))
+
+	theWar := databaseMap.Wars["warForB"]
+	assert.Len(t, theWar.Battles, 1)
+
+	battle := theWar.Battles[0]
+	assert.Equal(t, 1, battle.Year)
+	assert.Equal(t, "B", battle.TerritoryID)
+	assert.Equal(t, 50+2*forcesPerRegiment, battle.AttackerForces)
+	assert.Equal(t, 50, battle.DefenderForces)
+	assert.Equal(t, 10, Abs(battle.ScoreDelta))
+	assert.Equal(t, theWar.Score, battle.ScoreDelta)
+
+	if battle.Roll < battle.DefenderForces {
+		assert.Equal(t, "defender", battle.Winner)
+		assert.Less(t, battle.ScoreDelta, 0)
+		assert.Equal(t, 1, battle.RegimentsLost)
+	} else {
+		assert.Equal(t, "attacker", battle.Winner)
+		assert.Greater(t, battle.ScoreDelta, 0)
+		assert.Zero(t, battle.RegimentsLost)
+	}
+}
+
+func TestAWarWithoutArmiesAnywhereIsWonByTheDefenderHoldingOut(t *testing.T) {
+
+	nationStatesProvider := makeBattleTestNations(map[string]int{"attacker": 50, "defender": 50})
+	databaseMap := makeBattleTestMap(1)
+	delete(databaseMap.Armies, "1")
+
+	for year := 1; year <= 3; year++ {
+		databaseMap.Year = year
+		assert.NoError(t, ResolveBattles(&databaseMap, nationStatesProvider, rand.New(rand.NewSource(int64(year)))))
+	}
+
+	theWar := databaseMap.Wars["warForB"]
+	assert.True(t, theWar.IsOngoing)
+	assert.Equal(t, -60, theWar.Score)
+
+	databaseMap.Year = 4
+	assert.NoError(t, ResolveBattles(&databaseMap, nationStatesProvider, rand.New(rand.NewSource(4))))
+
+	theWar = databaseMap.Wars["warForB"]
+	assert.False(t, theWar.IsOngoing)
+	assert.Empty(t, theWar.Battles)
+	assert.Equal(t, databasemap.OutcomeVictory, theWar.Outcome)
+	assert.Equal(t, "defender", theWar.Victor)
+	assert.Equal(t, "defender", databaseMap.Cells["B"].Resident)
+}
+
+func TestAWarscoreInTheAttackersFavorWinsThemTheTerritory(t *testing.T) {
+
+	nationStatesProvider := makeBattleTestNations(map[string]int{"attacker": 50, "defender": 50})
+	databaseMap := makeBattleTestMap(1)
+	delete(databaseMap.Armies, "1")
+
+	theWar := databaseMap.Wars["warForB"]
+	theWar.Score = 100
+	databaseMap.Wars["warForB"] = theWar
+	databaseMap.Year = 0
+
+	assert.NoError(t, ResolveBattles(&databaseMap, nationStatesProvider, rand.New(rand.NewSource(1))))
+
+	theWar = databaseMap.Wars["warForB"]
+	assert.False(t, theWar.IsOngoing)
+	assert.Equal(t, "attacker", theWar.Victor)
+	assert.Equal(t, "attacker", databaseMap.Cells["B"].Resident)
+}
+
+func TestAnArmyOfANationAtPeaceDoesntFight(t *testing.T) {
+
+	nationStatesProvider := makeBattleTestNations(map[string]int{"attacker": 50, "defender": 50})
+	databaseMap := makeBattleTestMap(3)
+	databaseMap.Wars = map[string]databasemap.DatabaseWar{}
+
+	assert.NoError(t, ResolveBattles(&databaseMap, nationStatesProvider, rand.New(rand.NewSource(1))))
+
+	assert.Equal(t, 3, databaseMap.GetRegiments("attacker"))
+	assert.Equal(t, "defender", databaseMap.Cells["B"].Resident)
+}
+
+func TestAWarInCeasefireDoesntFight(t *testing.T) {
+
+	nationStatesProvider := makeBattleTestNations(map[string]int{"attacker": 50, "defender": 50})
+	databaseMap := makeBattleTestMap(1)
+	theWar := databaseMap.Wars["warForB"]
+	theWar.CeasefireUntilYear = 2
+	databaseMap.Wars["warForB"] = theWar
+
+	for _, year := range []int{1, 2} {
+		databaseMap.Year = year
+		assert.NoError(t, ResolveBattles(&databaseMap, nationStatesProvider, rand.New(rand.NewSource(1))))
+	}
+	assert.Empty(t, databaseMap.Wars["warForB"].Battles)
+
+	databaseMap.Year = 3
+	assert.NoError(t, ResolveBattles(&databaseMap, nationStatesProvider, rand.New(rand.NewSource(1))))
+	assert.Len(t, databaseMap.Wars["warForB"].Battles, 1)
+}
+
+func TestAnArmyThatDefeatsTheGarrisonTakesTheTerritoryAndWinsTheWar(t *testing.T) {
+
+	nationStatesProvider := makeBattleTestNations(map[string]int{"attacker": 50, "defender": 50})
+	databaseMap := makeBattleTestMap(100)
+	random := rand.New(rand.NewSource(1))
+
+	for databaseMap.Wars["warForB"].IsOngoing && databaseMap.Year < 20 {
+		assert.NoError(t, ResolveBattles(&databaseMap, nationStatesProvider, random))
+		databaseMap.Year++
+	}
+
+	theWar := databaseMap.Wars["warForB"]
+	assert.False(t, theWar.IsOngoing)
+	assert.Equal(t, databasemap.OutcomeVictory, theWar.Outcome)
+	assert.Equal(t, "attacker", theWar.Victor)
+	assert.Equal(t, "attacker", databaseMap.Cells["B"].Resident)
+	assert.Equal(t, "attacker", theWar.Battles[len(theWar.Battles)-1].Winner)
+}
+
+func TestDefendingRegimentsMustAllBeDefeatedBeforeATerritoryFalls(t *testing.T) {
+
+	nationStatesProvider := makeBattleTestNations(map[string]int{"attacker": 50, "defender": 50})
+	databaseMap := makeBattleTestMap(100)
+	databaseMap.Armies["2"] = databasemap.DatabaseArmy{ID: "2", Owner: "defender", TerritoryID: "B", Regiments: 4}
+
+	assert.NoError(t, ResolveBattles(&databaseMap, nationStatesProvider, rand.New(rand.NewSource(1))))
+
+	assert.True(t, databaseMap.Wars["warForB"].IsOngoing)
+	assert.Equal(t, "defender", databaseMap.Cells["B"].Resident)
+	assert.NotZero(t, databaseMap.RegimentsAt("B", []string{"defender"}))
+}
+
+func TestAnArmyCanTakeAnyTerritoryOfTheEnemyWithoutEndingTheWar(t *testing.T) {
+
+	nationStatesProvider := makeBattleTestNations(map[string]int{"attacker": 50, "defender": 50})
+	databaseMap := makeBattleTestMap(100)
+	databaseMap.SetResident("C", "defender")
+	databaseMap.Armies["1"] = databasemap.DatabaseArmy{ID: "1", Owner: "attacker", TerritoryID: "C", Regiments: 100}
+	random := rand.New(rand.NewSource(1))
+
+	for databaseMap.Cells["C"].Resident == "defender" && databaseMap.Year < 20 {
+		assert.NoError(t, ResolveBattles(&databaseMap, nationStatesProvider, random))
+		databaseMap.Year++
+	}
+
+	assert.Equal(t, "attacker", databaseMap.Cells["C"].Resident)
+	assert.True(t, databaseMap.Wars["warForB"].IsOngoing)
+	assert.Equal(t, "C", databaseMap.Wars["warForB"].Battles[0].TerritoryID)
+}
+
+func TestTheLosersOfABattleLoseHalfTheirRegiments(t *testing.T) {
+
+	nationStatesProvider := makeBattleTestNations(map[string]int{"attacker": 50, "defender": 50})
+	databaseMap := makeBattleTestMap(3)
+	databaseMap.Armies["2"] = databasemap.DatabaseArmy{ID: "2", Owner: "defender", TerritoryID: "B", Regiments: 3}
+
+	assert.NoError(t, ResolveBattles(&databaseMap, nationStatesProvider, rand.New(rand.NewSource(1))))
+
+	battle := databaseMap.Wars["warForB"].Battles[0]
+	assert.Equal(t, 2, battle.RegimentsLost)
+	assert.Equal(t, 3+3-2, databaseMap.GetRegiments("attacker")+databaseMap.GetRegiments("defender"))
+}
+
+func TestAnArmyWipedOutInABattleIsDisbanded(t *testing.T) {
+
+	nationStatesProvider := makeBattleTestNations(map[string]int{"attacker": 50, "defender": 50})
+	databaseMap := makeBattleTestMap(1)
+	databaseMap.Armies["2"] = databasemap.DatabaseArmy{ID: "2", Owner: "defender", TerritoryID: "B", Regiments: 1}
+
+	assert.NoError(t, ResolveBattles(&databaseMap, nationStatesProvider, rand.New(rand.NewSource(1))))
+
+	assert.Len(t, databaseMap.Armies, 1)
+}
+
+func TestMorePowerfulNationDoesntAlwaysWinWar(t *testing.T) {
+
+	nationStatesProvider := makeBattleTestNations(map[string]int{"attacker": 40, "defender": 60})
+	random := rand.New(rand.NewSource(1))
+
+	attackerWinCount := 0
+	defenderWinCount := 0
+
+	totalNumberOfSimulations := 2000
+
+	for simulationIndex := 0; simulationIndex < totalNumberOfSimulations; simulationIndex++ {
+		databaseMap := makeBattleTestMap(1)
+
+		for databaseMap.Wars["warForB"].IsOngoing && databaseMap.GetRegiments("attacker") != 0 {
+			assert.NoError(t, ResolveBattles(&databaseMap, nationStatesProvider, random))
+			databaseMap.Year++
+		}
+
+		if databaseMap.Cells["B"].Resident == "attacker" {
+			attackerWinCount++
+		} else {
+			defenderWinCount++
+		}
+	}
+
+	assert.Greater(t, attackerWinCount, totalNumberOfSimulations/10)
+	assert.Greater(t, defenderWinCount, totalNumberOfSimulations/10)
+	assert.Greater(t, attackerWinCount, defenderWinCount)
+}
+
+func TestABattleCombinesTheForcesOfEachCoalitionThere(t *testing.T) {
+
+	nationStatesProvider := makeBattleTestNations(map[string]int{"attacker": 20, "attackerAlly": 70, "defender": 50, "defenderAlly1": 90, "defenderAlly2": 60})
+	databaseMap := makeBattleTestMap(1)
+	theWar := databaseMap.Wars["warForB"]
+	theWar.AttackerAllies = []string{"attackerAlly"}
+	theWar.DefenderAllies = []string{"defenderAlly1", "defenderAlly2"}
+	databaseMap.Wars["warForB"] = theWar
+	databaseMap.Armies["2"] = databasemap.DatabaseArmy{ID: "2", Owner: "attackerAlly", TerritoryID: "B", Regiments: 1}
+	databaseMap.Armies["3"] = databasemap.DatabaseArmy{ID: "3", Owner: "defenderAlly1", TerritoryID: "B", Regiments: 1}
+
+	assert.NoError(t, ResolveBattles(&databaseMap, nationStatesProvider, rand.New(rand.NewSource(1))))
+
+	battle := databaseMap.Wars["warForB"].Battles[0]
+	assert.Equal(t, 80+forcesPerRegiment+30+forcesPerRegiment, battle.AttackerForces)
+	assert.Equal(t, 50+10+forcesPerRegiment, battle.DefenderForces)
+}
+
+func TestADefenderFightingBackInTheAttackersTerritoryIsLoggedFromTheWarsSide(t *testing.T) {
+
+	nationStatesProvider := makeBattleTestNations(map[string]int{"attacker": 50, "defender": 50})
+	databaseMap := makeBattleTestMap(1)
+	databaseMap.Armies["1"] = databasemap.DatabaseArmy{ID: "1", Owner: "defender", TerritoryID: "A", Regiments: 2}
+
+	assert.NoError(t, ResolveBattles(&databaseMap, nationStatesProvider, rand.New(rand.NewSource(1))))
+
+	battle := databaseMap.Wars["warForB"].Battles[0]
+	assert.Equal(t, "A", battle.TerritoryID)
+	assert.Equal(t, 50, battle.AttackerForces)
+	assert.Equal(t, 50+2*forcesPerRegiment, battle.DefenderForces)
+}
@@ -0,0 +1,208 @@
+package war
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/brickman1444/NSImperialism/databasemap"
+	"github.com/brickman1444/NSImperialism/nationstates_api"
+)
+
+type CombatModel struct {
+	ID      string
+	Name    string
+	Weights []databasemap.DatabaseCensusWeight
+}
+
+var MilitaryCombatModel = CombatModel{
+	ID:   "military",
+	Name: "Military",
+	Weights: []databasemap.DatabaseCensusWeight{
+		{Scale: nationstates_api.CENSUSSCALEDEFENSEFORCES, Weight: 1},
+	},
+}
+
+var CombatModels = []CombatModel{
+	MilitaryCombatModel,
+	{
+		ID:   "balanced",
+		Name: "Balanced",
+		Weights: []databasemap.DatabaseCensusWeight{
+			{Scale: nationstates_api.CENSUSSCALEDEFENSEFORCES, Weight: 2},
+			{Scale: nationstates_api.CENSUSSCALESCIENTIFICADVANCEMENT, Weight: 1},
+			{Scale: nationstates_api.CENSUSSCALEECONOMY, Weight: 1},
+			{Scale: nationstates_api.CENSUSSCALEPOPULATION, Weight: 1},
+		},
+	},
+	{
+		ID:   "technological",
+		Name: "Technological",
+		Weights: []databasemap.DatabaseCensusWeight{
+			{Scale: nationstates_api.CENSUSSCALESCIENTIFICADVANCEMENT, Weight: 2},
+			{Scale: nationstates_api.CENSUSSCALEDEFENSEFORCES, Weight: 1},
+			{Scale: nationstates_api.CENSUSSCALEECONOMY, Weight: 1},
+		},
+	},
+	{
+		ID:   "industrial",
+		Name: "Industrial",
+		Weights: []databasemap.DatabaseCensusWeight{
+			{Scale: nationstates_api.CENSUSSCALEECONOMY, Weight: 2},
+			{Scale: nationstates_api.CENSUSSCALEPOPULATION, Weight: 2},
+			{Scale: nationstates_api.CENSUSSCALEDEFENSEFORCES, Weight: 1},
+		},
+	},
+}
+
+func DescribeCensusWeights(weights []databasemap.DatabaseCensusWeight) string {
+	descriptions := []string{}
+	for _, weight := range weights {
+		descriptions = append(descriptions, fmt.Sprintf("%s ×%d", nationstates_api.CensusScaleName(weight.Scale), weight.Weight))
+	}
+	return strings.Join(descriptions, ", ")
+}
+
+func (combatModel CombatModel) Description() string {
+	return DescribeCensusWeights(combatModel.Weights)
+}
+
+func FindCombatModel(id string) (CombatModel, bool) {
+	for _, combatModel := range CombatModels {
+		if combatModel.ID == id {
+			return combatModel, true
+		}
+	}
+	return CombatModel{}, false
+}
+
+// GetCensusWeights falls back to the military model for maps created before weights were stored
+func GetCensusWeights(databaseMap databasemap.DatabaseMap) []databasemap.DatabaseCensusWeight {
+	if len(databaseMap.CensusWeights) == 0 {
+		return MilitaryCombatModel.Weights
+	}
+	return databaseMap.CensusWeights
+}
+
+// Strength is the weighted average of a nation's inverted census ranks from 0 to 100
+func Strength(nation nationstates_api.Nation, weights []databasemap.DatabaseCensusWeight) int {
+	if len(weights) == 0 {
+		weights = MilitaryCombatModel.Weights
+	}
+
+	weightedStrength := 0
+	totalWeight := 0
+	for _, weight := range weights {
+		weightedStrength += weight.Weight * (100 - nation.GetCensusRank(weight.Scale))
+		totalWeight += weight.Weight
+	}
+
+	if totalWeight == 0 {
+		return 100 - nation.GetDefenseForces()
+	}
+
+	return weightedStrength / totalWeight
+}
+
+// Every territory beyond the first and every war beyond the first spreads a nation's military thinner
+const overextensionPercentPerTerritory = 5
+const overextensionPercentPerWar = 25
+
+// Battlefield is what a map's wars are fought under for a year: the combat model and how thinly each nation is spread
+type Battlefield struct {
+	CensusWeights   []databasemap.DatabaseCensusWeight
+	TerritoriesHeld map[string]int
+	OngoingWars     map[string]int
+	Cells           map[string]databasemap.DatabaseCell
+	Armies          map[string]databasemap.DatabaseArmy
+}
+
+func NewBattlefield(databaseMap databasemap.DatabaseMap) Battlefield {
+	battlefield := Battlefield{
+		CensusWeights:   GetCensusWeights(databaseMap),
+		TerritoriesHeld: make(map[string]int),
+		OngoingWars:     make(map[string]int),
+		Cells:           databaseMap.Cells,
+		Armies:          databaseMap.Armies,
+	}
+
+	for _, cell := range databaseMap.Cells {
+		if len(cell.Resident) != 0 {
+			battlefield.TerritoriesHeld[cell.Resident]++
+		}
+	}
+
+	for _, databaseWar := range databaseMap.Wars {
+		if databaseWar.IsOngoing {
+			for _, nationID := range append(databaseWar.GetAttackers(), databaseWar.GetDefenders()...) {
+				battlefield.OngoingWars[nationID]++
+			}
+		}
+	}
+
+	return battlefield
+}
+
+const forcesPerRegiment = 5
+
+// ArmyForces is what the regiments the nations have stationed in a territory add to a battle there
+func (battlefield Battlefield) ArmyForces(territoryID string, nationIDs []string) int {
+	regiments := 0
+	for _, army := range battlefield.Armies {
+		if army.TerritoryID == territoryID {
+			for _, nationID := range nationIDs {
+				if army.Owner == nationID {
+					regiments += army.Regiments
+				}
+			}
+		}
+	}
+	return forcesPerRegiment * regiments
+}
+
+// Readiness is the percentage of its strength a nation can bring to any one war
+func (battlefield Battlefield) Readiness(nationID string) int {
+	overextensionPercent := 100
+	if battlefield.TerritoriesHeld[nationID] > 1 {
+		overextensionPercent += overextensionPercentPerTerritory * (battlefield.TerritoriesHeld[nationID] - 1)
+	}
+	if battlefield.OngoingWars[nationID] > 1 {
+		overextensionPercent += overextensionPercentPerWar * (battlefield.OngoingWars[nationID] - 1)
+	}
+	return 100 * 100 / overextensionPercent
+}
+
+// Forces is the strength a nation brings to a single war once its commitments elsewhere are accounted for
+func (battlefield Battlefield) Forces(nation nationstates_api.Nation) int {
+	forces := Strength(nation, battlefield.CensusWeights) * battlefield.Readiness(nation.Id) / 100
+	if forces < 1 {
+		return 1
+	}
+	return forces
+}
+
+const fortificationDefenseBonusPercentPerLevel = 20
+
+func terrainDefenseBonusPercent(terrain databasemap.Terrain) int {
+	switch terrain {
+	case databasemap.Forest:
+		return 15
+	case databasemap.Hills:
+		return 25
+	case databasemap.Mountains:
+		return 50
+	case databasemap.Coast:
+		return 10 // Attackers have to land from the sea
+	case databasemap.Marsh:
+		return 20
+	}
+	return 0
+}
+
+// DefenseBonusPercent is how much the terrain and fortifications of a territory add to its defenders' forces
+func DefenseBonusPercent(territory databasemap.DatabaseCell) int {
+	return terrainDefenseBonusPercent(territory.Terrain) + fortificationDefenseBonusPercentPerLevel*territory.FortificationLevel
+}
+
+func (battlefield Battlefield) DefenseBonusPercent(territoryID string) int {
+	return DefenseBonusPercent(battlefield.Cells[territoryID])
+}
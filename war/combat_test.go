@@ -0,0 +1,191 @@
+package war
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/brickman1444/NSImperialism/databasemap"
+	"github.com/brickman1444/NSImperialism/nationstates_api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStrengthIsTheWeightedAverageOfInvertedCensusRanks(t *testing.T) {
+
+	nation := nationstates_api.Nation{}
+	nation.SetDefenseForces(10)
+	nation.SetCensusRank(70, nationstates_api.CENSUSSCALEECONOMY)
+
+	weights := []databasemap.DatabaseCensusWeight{
+		{Scale: nationstates_api.CENSUSSCALEDEFENSEFORCES, Weight: 3},
+		{Scale: nationstates_api.CENSUSSCALEECONOMY, Weight: 1},
+	}
+
+	assert.Equal(t, (3*90+1*30)/4, Strength(nation, weights))
+}
+
+func TestStrengthWithoutWeightsUsesDefenseForces(t *testing.T) {
+
+	nation := nationstates_api.Nation{}
+	nation.SetDefenseForces(25)
+	nation.SetCensusRank(0, nationstates_api.CENSUSSCALEECONOMY)
+
+	assert.Equal(t, 75, Strength(nation, nil))
+	assert.Equal(t, 75, Strength(nation, []databasemap.DatabaseCensusWeight{{Scale: nationstates_api.CENSUSSCALEECONOMY, Weight: 0}}))
+}
+
+func TestMapsWithoutCensusWeightsUseTheMilitaryModel(t *testing.T) {
+
+	databaseMap := databasemap.NewBlankDatabaseMap()
+	assert.Equal(t, MilitaryCombatModel.Weights, GetCensusWeights(databaseMap))
+
+	balanced, doesCombatModelExist := FindCombatModel("balanced")
+	assert.True(t, doesCombatModelExist)
+
+	databaseMap.CensusWeights = balanced.Weights
+	assert.Equal(t, balanced.Weights, GetCensusWeights(databaseMap))
+
+	_, doesCombatModelExist = FindCombatModel("missing")
+	assert.False(t, doesCombatModelExist)
+}
+
+func TestEveryCombatModelOnlyUsesFetchedCensusScales(t *testing.T) {
+
+	for _, combatModel := range CombatModels {
+		for _, weight := range combatModel.Weights {
+			assert.Contains(t, nationstates_api.CombatCensusScales, weight.Scale, combatModel.ID)
+		}
+	}
+}
+
+func TestReadinessFallsWithTerritoriesHeldAndWarsFought(t *testing.T) {
+
+	databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A", "B", "C", "D", "E"})
+	databaseMap.SetResident("A", "sprawling")
+	databaseMap.SetResident("B", "sprawling")
+	databaseMap.SetResident("C", "sprawling")
+	databaseMap.SetResident("D", "compact")
+	databaseMap.SetResident("E", "bystander")
+
+	battlefield := NewBattlefield(databaseMap)
+	assert.Equal(t, 3, battlefield.TerritoriesHeld["sprawling"])
+	assert.Equal(t, 100, battlefield.Readiness("compact"))
+	assert.Less(t, battlefield.Readiness("sprawling"), 100)
+
+	databaseMap.PutWars([]databasemap.DatabaseWar{
+		databasemap.NewWar("compact", "sprawling", "warForA", "A", 0),
+		databasemap.NewWar("compact", "bystander", "warForE", "E", 0),
+	})
+
+	battlefield = NewBattlefield(databaseMap)
+	assert.Equal(t, 2, battlefield.OngoingWars["compact"])
+	assert.Equal(t, 1, battlefield.OngoingWars["bystander"])
+	assert.Less(t, battlefield.Readiness("compact"), 100)
+	assert.Equal(t, 100, battlefield.Readiness("bystander"))
+}
+
+func TestFinishedWarsDontOverextendANation(t *testing.T) {
+
+	databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A"})
+	finishedWar := databasemap.NewWar("attacker", "defender", "warForA", "A", 0)
+	finishedWar.IsOngoing = false
+	databaseMap.PutWars([]databasemap.DatabaseWar{finishedWar, databasemap.NewWar("attacker", "defender", "otherWarForA", "A", 0)})
+
+	assert.Equal(t, 1, NewBattlefield(databaseMap).OngoingWars["attacker"])
+}
+
+func TestANationFightingManyWarsIsWeakerInEach(t *testing.T) {
+
+	nationStatesProvider := nationstates_api.NewNationStatesProviderSimpleMap()
+	for _, nationID := range []string{"aggressor", "defender1", "defender2", "defender3"} {
+		nation := nationstates_api.Nation{Id: nationID}
+		nation.SetDefenseForces(50)
+		nationStatesProvider.PutNationData(nation)
+	}
+
+	databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A", "B", "C", "D"})
+	databaseMap.SetResident("A", "aggressor")
+	databaseMap.SetResident("B", "defender1")
+	databaseMap.SetResident("C", "defender2")
+	databaseMap.SetResident("D", "defender3")
+	databaseMap.PutWars([]databasemap.DatabaseWar{
+		databasemap.NewWar("aggressor", "defender1", "warForB", "B", 0),
+		databasemap.NewWar("aggressor", "defender2", "warForC", "C", 0),
+		databasemap.NewWar("aggressor", "defender3", "warForD", "D", 0),
+	})
+	databaseMap.Armies["1"] = databasemap.DatabaseArmy{ID: "1", Owner: "aggressor", TerritoryID: "B", Regiments: 1}
+	databaseMap.Year = 1
+
+	err := ResolveBattles(&databaseMap, nationStatesProvider, rand.New(rand.NewSource(1)))
+	assert.NoError(t, err)
+
+	theWar := databaseMap.Wars["warForB"]
+	assert.Equal(t, 50, theWar.Battles[0].DefenderForces)
+	assert.Equal(t, 50*100/150+forcesPerRegiment, theWar.Battles[0].AttackerForces)
+}
+
+func TestEveryNationFieldsAtLeastATokenForce(t *testing.T) {
+
+	nation := nationstates_api.Nation{Id: "feeble"}
+	nation.SetDefenseForces(100)
+
+	assert.Equal(t, 1, Battlefield{}.Forces(nation))
+}
+
+func TestTerrainAndFortificationsStrengthenDefenders(t *testing.T) {
+
+	nationStatesProvider := nationstates_api.NewNationStatesProviderSimpleMap()
+	for _, nationID := range []string{"attacker", "defender"} {
+		nation := nationstates_api.Nation{Id: nationID}
+		nation.SetDefenseForces(50)
+		nationStatesProvider.PutNationData(nation)
+	}
+
+	databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A", "B"})
+	databaseMap.Cells["B"] = databasemap.DatabaseCell{ID: "B", Terrain: databasemap.Mountains, FortificationLevel: 1}
+	databaseMap.SetResident("A", "attacker")
+	databaseMap.SetResident("B", "defender")
+
+	battlefield := NewBattlefield(databaseMap)
+	assert.Equal(t, 70, battlefield.DefenseBonusPercent("B"))
+	assert.Equal(t, 0, battlefield.DefenseBonusPercent("A"))
+
+	databaseMap.PutWars([]databasemap.DatabaseWar{databasemap.NewWar("attacker", "defender", "warForB", "B", 0)})
+	databaseMap.Armies["1"] = databasemap.DatabaseArmy{ID: "1", Owner: "attacker", TerritoryID: "B", Regiments: 1}
+	databaseMap.Year = 1
+
+	err := ResolveBattles(&databaseMap, nationStatesProvider, rand.New(rand.NewSource(1)))
+	assert.NoError(t, err)
+
+	theWar := databaseMap.Wars["warForB"]
+	assert.Equal(t, 50+forcesPerRegiment, theWar.Battles[0].AttackerForces)
+	assert.Equal(t, 85, theWar.Battles[0].DefenderForces)
+}
+
+func TestRegimentsInTheContestedTerritoryAndFundingAddToForces(t *testing.T) {
+
+	nationStatesProvider := nationstates_api.NewNationStatesProviderSimpleMap()
+	for _, nationID := range []string{"attacker", "defender"} {
+		nation := nationstates_api.Nation{Id: nationID}
+		nation.SetDefenseForces(50)
+		nationStatesProvider.PutNationData(nation)
+	}
+
+	databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A", "B"})
+	databaseMap.SetResident("A", "defender")
+	databaseMap.SetResident("B", "defender")
+	databaseMap.Armies["1"] = databasemap.DatabaseArmy{ID: "1", Owner: "attacker", TerritoryID: "A", Regiments: 2}
+	databaseMap.Armies["2"] = databasemap.DatabaseArmy{ID: "2", Owner: "defender", TerritoryID: "B", Regiments: 3}
+	databaseMap.Year = 1
+
+	theWar := databasemap.NewWar("attacker", "defender", "warForA", "A", 0)
+	theWar.DefenderFunding = 7
+	databaseMap.PutWars([]databasemap.DatabaseWar{theWar})
+
+	err := ResolveBattles(&databaseMap, nationStatesProvider, rand.New(rand.NewSource(1)))
+	assert.NoError(t, err)
+
+	theWar = databaseMap.Wars["warForA"]
+	assert.Equal(t, 50+2*forcesPerRegiment, theWar.Battles[0].AttackerForces)
+	assert.Equal(t, 50*100/105+7, theWar.Battles[0].DefenderForces)
+	assert.Zero(t, theWar.DefenderFunding)
+}
@@ -0,0 +1,42 @@
+package war
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/brickman1444/NSImperialism/databasemap"
+)
+
+// CheckOccasion returns why the attacker can't go to war over the territory for the given occasion
+func CheckOccasion(occasion databasemap.Occasion, attackerID string, territory databasemap.DatabaseCell) error {
+	switch occasion {
+	case databasemap.Conquest, databasemap.HolyWar:
+		return nil
+	case databasemap.Reconquest:
+		if !territory.WasHeldBy(attackerID) {
+			return fmt.Errorf("You can only reconquer %s if you held it before", territory.ID)
+		}
+		return nil
+	case databasemap.Liberation:
+		formerResident := territory.FormerResident()
+		if len(formerResident) == 0 {
+			return fmt.Errorf("%s has never been conquered so there's no one to liberate it for", territory.ID)
+		}
+		if formerResident == attackerID {
+			return fmt.Errorf("Declare a reconquest to take back %s for yourself", territory.ID)
+		}
+		return nil
+	}
+	return errors.New("You didn't choose a valid occasion for war")
+}
+
+// scoreDeltaPerYear is how far a battle swings the warscore for each year the war has gone on
+func scoreDeltaPerYear(occasion databasemap.Occasion) int {
+	switch occasion {
+	case databasemap.HolyWar:
+		return 15 // Neither side will settle for less than total victory
+	case databasemap.Reconquest:
+		return 12 // The population remembers its old rulers
+	}
+	return 10
+}
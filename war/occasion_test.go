@@ -0,0 +1,56 @@
+package war
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/brickman1444/NSImperialism/databasemap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconquestNeedsTheTerritoryToHaveBeenHeldBefore(t *testing.T) {
+
+	territory := databasemap.DatabaseCell{ID: "A", Resident: "occupier", FormerResidents: []string{"original"}}
+
+	assert.NoError(t, CheckOccasion(databasemap.Reconquest, "original", territory))
+	assert.Error(t, CheckOccasion(databasemap.Reconquest, "stranger", territory))
+}
+
+func TestLiberationNeedsAConqueredTerritory(t *testing.T) {
+
+	neverConquered := databasemap.DatabaseCell{ID: "A", Resident: "original"}
+	assert.Error(t, CheckOccasion(databasemap.Liberation, "liberator", neverConquered))
+
+	conquered := databasemap.DatabaseCell{ID: "A", Resident: "occupier", FormerResidents: []string{"original"}}
+	assert.NoError(t, CheckOccasion(databasemap.Liberation, "liberator", conquered))
+	assert.Error(t, CheckOccasion(databasemap.Liberation, "original", conquered))
+}
+
+func TestConquestAndHolyWarCanBeDeclaredAnywhere(t *testing.T) {
+
+	territory := databasemap.DatabaseCell{ID: "A", Resident: "defender"}
+
+	assert.NoError(t, CheckOccasion(databasemap.Conquest, "attacker", territory))
+	assert.NoError(t, CheckOccasion(databasemap.HolyWar, "attacker", territory))
+	assert.Error(t, CheckOccasion(databasemap.Occasion("Conquest of"), "attacker", territory))
+	assert.Error(t, CheckOccasion(databasemap.Occasion(""), "attacker", territory))
+}
+
+func TestAHolyWarSwingsTheScoreFurtherThanAConquest(t *testing.T) {
+
+	nationStatesProvider := makeBattleTestNations(map[string]int{"attacker": 50, "defender": 50})
+
+	conquestMap := makeBattleTestMap(1)
+	conquest := conquestMap.Wars["warForB"]
+	conquest.Occasion = databasemap.Conquest
+	conquestMap.Wars["warForB"] = conquest
+	assert.NoError(t, ResolveBattles(&conquestMap, nationStatesProvider, rand.New(rand.NewSource(1))))
+
+	holyWarMap := makeBattleTestMap(1)
+	holyWar := holyWarMap.Wars["warForB"]
+	holyWar.Occasion = databasemap.HolyWar
+	holyWarMap.Wars["warForB"] = holyWar
+	assert.NoError(t, ResolveBattles(&holyWarMap, nationStatesProvider, rand.New(rand.NewSource(1))))
+
+	assert.Greater(t, Abs(holyWarMap.Wars["warForB"].Score), Abs(conquestMap.Wars["warForB"].Score))
+}
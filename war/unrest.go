@@ -0,0 +1,80 @@
+package war
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/brickman1444/NSImperialism/databasemap"
+	"github.com/brickman1444/NSImperialism/nationstates_api"
+)
+
+const baseUprisingPercent = 10
+const maximumUprisingPercent = 30
+const uprisingPercentPerYearHeld = 1 // Unrest dies down the longer an occupation lasts
+const uprisingPercentPerRegiment = 3 // Garrisoned regiments keep the peace
+const repressionPerUprisingPercent = 5
+
+// Repression is how badly a nation ranks for civil rights and political freedom, from 0 for the freest nations to 100 for the most repressive
+func Repression(occupier nationstates_api.Nation) int {
+	repression := 0
+	for _, scale := range nationstates_api.UnrestCensusScales {
+		repression += occupier.GetCensusRank(scale)
+	}
+	return repression / len(nationstates_api.UnrestCensusScales)
+}
+
+// UprisingPercent is the chance each year that the people of an occupied territory rise up against the occupier
+func UprisingPercent(territory databasemap.DatabaseCell, occupier nationstates_api.Nation, garrisonRegiments int, currentYear int) int {
+	// The people need a year to organise after a conquest
+	if !territory.IsOccupied() || currentYear <= territory.HeldSinceYear {
+		return 0
+	}
+
+	uprisingPercent := baseUprisingPercent + Repression(occupier)/repressionPerUprisingPercent
+	uprisingPercent -= (currentYear - territory.HeldSinceYear) * uprisingPercentPerYearHeld
+	uprisingPercent -= garrisonRegiments * uprisingPercentPerRegiment
+
+	if uprisingPercent < 0 {
+		return 0
+	}
+	if uprisingPercent > maximumUprisingPercent {
+		return maximumUprisingPercent
+	}
+	return uprisingPercent
+}
+
+// RollUprisings gives every occupied territory a chance to rise up in a war of liberation for its original resident
+func RollUprisings(databaseMap *databasemap.DatabaseMap, nationStatesProvider nationstates_api.NationStatesProvider, random *rand.Rand) error {
+
+	for _, territoryID := range databaseMap.GetCellIDs() {
+		territory := databaseMap.Cells[territoryID]
+		originalResident := territory.OriginalResident
+
+		if !territory.IsOccupied() || FindOngoingWarAt(databaseMap.GetWars(), territoryID) != nil || databaseMap.AreAllied(originalResident, territory.Resident) || !databaseMap.HoldsAnyTerritory(originalResident) {
+			continue
+		}
+
+		occupier, err := nationStatesProvider.GetNationData(territory.Resident)
+		if err != nil {
+			return err
+		}
+
+		garrisonRegiments := databaseMap.RegimentsAt(territoryID, []string{territory.Resident})
+		if random.Intn(100) >= UprisingPercent(territory, *occupier, garrisonRegiments, databaseMap.Year) {
+			continue
+		}
+
+		rebels, err := nationStatesProvider.GetNationData(originalResident)
+		if err != nil {
+			return err
+		}
+
+		warName := fmt.Sprintf("The %s Uprising in %s of Year %d", rebels.Demonym, territory.DisplayName(), databaseMap.Year)
+		uprising := databasemap.NewWar(originalResident, territory.Resident, warName, territoryID, databaseMap.Year)
+		uprising.Occasion = databasemap.Liberation
+		uprising.LiberatedFor = originalResident
+		databaseMap.PutWars([]databasemap.DatabaseWar{uprising})
+	}
+
+	return nil
+}
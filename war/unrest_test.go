@@ -0,0 +1,112 @@
+package war
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/brickman1444/NSImperialism/databasemap"
+	"github.com/brickman1444/NSImperialism/nationstates_api"
+	"github.com/stretchr/testify/assert"
+)
+
+func makeOccupier(repression int) nationstates_api.Nation {
+	occupier := nationstates_api.Nation{Id: "occupier", Demonym: "Occupying"}
+	for _, scale := range nationstates_api.UnrestCensusScales {
+		occupier.SetCensusRank(repression, scale)
+	}
+	return occupier
+}
+
+func TestOnlyOccupiedTerritoriesRiseUp(t *testing.T) {
+
+	territory := databasemap.DatabaseCell{ID: "A", Resident: "occupier", OriginalResident: "occupier"}
+
+	assert.Equal(t, 0, UprisingPercent(territory, makeOccupier(100), 0, 5))
+}
+
+func TestRepressiveOccupiersFaceMoreUnrest(t *testing.T) {
+
+	territory := databasemap.DatabaseCell{ID: "A", Resident: "occupier", OriginalResident: "original", HeldSinceYear: 3}
+
+	assert.Equal(t, 0, UprisingPercent(territory, makeOccupier(100), 0, 3))
+	assert.Equal(t, baseUprisingPercent-1, UprisingPercent(territory, makeOccupier(0), 0, 4))
+	assert.Equal(t, baseUprisingPercent-1+100/repressionPerUprisingPercent, UprisingPercent(territory, makeOccupier(100), 0, 4))
+}
+
+func TestUnrestFadesOverTimeAndIsKeptDownByGarrisons(t *testing.T) {
+
+	territory := databasemap.DatabaseCell{ID: "A", Resident: "occupier", OriginalResident: "original"}
+	occupier := makeOccupier(50)
+
+	assert.Greater(t, UprisingPercent(territory, occupier, 0, 1), UprisingPercent(territory, occupier, 0, 5))
+	assert.Greater(t, UprisingPercent(territory, occupier, 0, 1), UprisingPercent(territory, occupier, 2, 1))
+	assert.Equal(t, 0, UprisingPercent(territory, occupier, 0, 100))
+}
+
+func TestAnUprisingIsAWarOfLiberationAgainstTheOccupier(t *testing.T) {
+
+	nationStatesProvider := nationstates_api.NewNationStatesProviderSimpleMap()
+	nationStatesProvider.PutNationData(makeOccupier(100))
+	nationStatesProvider.PutNationData(nationstates_api.Nation{Id: "original", Demonym: "Original"})
+
+	databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A", "B"})
+	databaseMap.Cells["A"] = databasemap.DatabaseCell{ID: "A", Name: "Alpha"}
+	databaseMap.SetResident("A", "original")
+	databaseMap.SetResident("B", "original")
+	databaseMap.Year = 1
+	databaseMap.SetResident("A", "occupier")
+
+	random := rand.New(rand.NewSource(1))
+	for databaseMap.Year = 2; databaseMap.Year < 100 && len(databaseMap.Wars) == 0; databaseMap.Year++ {
+		assert.NoError(t, RollUprisings(&databaseMap, nationStatesProvider, random))
+	}
+
+	wars := databaseMap.GetWars()
+	assert.Len(t, wars, 1)
+	assert.Contains(t, wars[0].ID, "Original Uprising in Alpha")
+	assert.Equal(t, "original", wars[0].Attacker)
+	assert.Equal(t, "occupier", wars[0].Defender)
+	assert.Equal(t, databasemap.Liberation, wars[0].Occasion)
+	assert.Equal(t, "original", wars[0].Claimant())
+
+	assert.NoError(t, RollUprisings(&databaseMap, nationStatesProvider, random))
+	assert.Len(t, databaseMap.Wars, 1)
+}
+
+func TestAlliesDontRiseUpAgainstEachOther(t *testing.T) {
+
+	nationStatesProvider := nationstates_api.NewNationStatesProviderSimpleMap()
+	nationStatesProvider.PutNationData(makeOccupier(100))
+
+	databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A", "B"})
+	databaseMap.SetResident("A", "original")
+	databaseMap.SetResident("B", "occupier")
+	databaseMap.SetResident("A", "occupier")
+	assert.NoError(t, databaseMap.ProposeAlliance("original", "occupier"))
+	assert.NoError(t, databaseMap.AcceptAlliance("occupier", "original"))
+
+	random := rand.New(rand.NewSource(1))
+	for databaseMap.Year = 1; databaseMap.Year < 10; databaseMap.Year++ {
+		assert.NoError(t, RollUprisings(&databaseMap, nationStatesProvider, random))
+	}
+
+	assert.Empty(t, databaseMap.Wars)
+}
+
+func TestANationWithNoTerritoriesLeftDoesntRiseUp(t *testing.T) {
+
+	nationStatesProvider := nationstates_api.NewNationStatesProviderSimpleMap()
+	nationStatesProvider.PutNationData(makeOccupier(100))
+	nationStatesProvider.PutNationData(nationstates_api.Nation{Id: "original", Demonym: "Original"})
+
+	databaseMap := databasemap.NewDatabaseMapWithTerritories([]string{"A"})
+	databaseMap.SetResident("A", "original")
+	databaseMap.SetResident("A", "occupier")
+
+	random := rand.New(rand.NewSource(1))
+	for databaseMap.Year = 2; databaseMap.Year < 100; databaseMap.Year++ {
+		assert.NoError(t, RollUprisings(&databaseMap, nationStatesProvider, random))
+	}
+
+	assert.Empty(t, databaseMap.Wars)
+}
@@ -3,7 +3,6 @@ package war
 import (
 	"fmt"
 	"html/template"
-	"math/rand"
 
 	"github.com/brickman1444/NSImperialism/databasemap"
 	"github.com/brickman1444/NSImperialism/nationstates_api"
@@ -58,41 +57,28 @@ func FindOngoingWarAt(wars []databasemap.DatabaseWar, territoryName string) *dat
 	return nil
 }
 
-const battleScoreDelta = 10
-
-func Tick(war *databasemap.DatabaseWar, nationStatesProvider nationstates_api.NationStatesProvider, currentYear int) (bool, error) {
-
-	if war.IsOngoing {
-
-		defender, err := nationStatesProvider.GetNationData(war.Defender)
-		if err != nil {
-			return false, err
-		}
-
-		attacker, err := nationStatesProvider.GetNationData(war.Attacker)
+// coalitionForces is the strength of every nation on one side plus the armies they have in the territory being fought over
+func coalitionForces(nationIDs []string, territoryID string, nationStatesProvider nationstates_api.NationStatesProvider, battlefield Battlefield) (int, error) {
+	forces := 0
+	for _, nationID := range nationIDs {
+		nation, err := nationStatesProvider.GetNationData(nationID)
 		if err != nil {
-			return false, err
-		}
-
-		// TODO: This should be divided by the number of territories controlled
-		defenderDefenseForcesInverted := 100 - defender.GetDefenseForces()
-		attackerDefenseForcesInverted := 100 - attacker.GetDefenseForces()
-
-		randomRoll := rand.Intn(defenderDefenseForcesInverted + attackerDefenseForcesInverted)
-
-		if randomRoll < defenderDefenseForcesInverted {
-			war.Score -= battleScoreDelta * (currentYear - war.StartYear)
-		} else {
-			war.Score += battleScoreDelta * (currentYear - war.StartYear)
+			return 0, err
 		}
 
-		if Abs(war.Score) >= 100 {
-			war.IsOngoing = false
-			return true, nil
-		}
+		forces += battlefield.Forces(*nation)
 	}
+	return forces + battlefield.ArmyForces(territoryID, nationIDs), nil
+}
 
-	return false, nil
+type RenderedBattle struct {
+	Year           int
+	Territory      string
+	AttackerForces int
+	DefenderForces int
+	Winner         template.HTML
+	ScoreDelta     int
+	RegimentsLost  int
 }
 
 type RenderedWar struct {
@@ -100,7 +86,68 @@ type RenderedWar struct {
 	Name             string
 	Attacker         template.HTML
 	Defender         template.HTML
+	AttackerAllies   []template.HTML
+	DefenderAllies   []template.HTML
 	ScoreDescription template.HTML
+	Battles          []RenderedBattle
+	Outcome          template.HTML
+	CeasefireUntil   int
+}
+
+func OutcomeDescription(war databasemap.DatabaseWar, attacker nationstates_api.Nation, defender nationstates_api.Nation) template.HTML {
+
+	victor := attacker
+	loser := defender
+	if war.Victor == defender.Id {
+		victor = defender
+		loser = attacker
+	}
+
+	switch war.Outcome {
+	case databasemap.OutcomeVictory:
+		return template.HTML(fmt.Sprintf("Won by %s", string(victor.FlagAndName())))
+	case databasemap.OutcomeSurrender:
+		return template.HTML(fmt.Sprintf("%s surrendered to %s", string(loser.FlagAndName()), string(victor.FlagAndName())))
+	case databasemap.OutcomeWhitePeace:
+		return template.HTML("Ended in a white peace")
+	}
+
+	return ""
+}
+
+func RenderBattles(war databasemap.DatabaseWar, attacker nationstates_api.Nation, defender nationstates_api.Nation) []RenderedBattle {
+	renderedBattles := []RenderedBattle{}
+	for _, battle := range war.Battles {
+
+		winner := defender.FlagAndName()
+		if battle.Winner == attacker.Id {
+			winner = attacker.FlagAndName()
+		}
+
+		renderedBattles = append(renderedBattles, RenderedBattle{
+			Year:           battle.Year,
+			Territory:      battle.TerritoryID,
+			AttackerForces: battle.AttackerForces,
+			DefenderForces: battle.DefenderForces,
+			Winner:         winner,
+			ScoreDelta:     Abs(battle.ScoreDelta),
+			RegimentsLost:  battle.RegimentsLost,
+		})
+	}
+	return renderedBattles
+}
+
+func renderAllies(allyIDs []string, nationStatesProvider nationstates_api.NationStatesProvider) ([]template.HTML, error) {
+	renderedAllies := []template.HTML{}
+	for _, allyID := range allyIDs {
+		ally, err := nationStatesProvider.GetNationData(allyID)
+		if err != nil {
+			return []template.HTML{}, err
+		}
+
+		renderedAllies = append(renderedAllies, ally.FlagAndName())
+	}
+	return renderedAllies, nil
 }
 
 func RenderWar(war databasemap.DatabaseWar, nationStatesProvider nationstates_api.NationStatesProvider) (RenderedWar, error) {
@@ -115,12 +162,27 @@ func RenderWar(war databasemap.DatabaseWar, nationStatesProvider nationstates_ap
 		return RenderedWar{}, err
 	}
 
+	attackerAllies, err := renderAllies(war.AttackerAllies, nationStatesProvider)
+	if err != nil {
+		return RenderedWar{}, err
+	}
+
+	defenderAllies, err := renderAllies(war.DefenderAllies, nationStatesProvider)
+	if err != nil {
+		return RenderedWar{}, err
+	}
+
 	return RenderedWar{
 		IsOngoing:        war.IsOngoing,
 		Name:             war.ID,
 		Attacker:         attacker.FlagAndName(),
 		Defender:         defender.FlagAndName(),
+		AttackerAllies:   attackerAllies,
+		DefenderAllies:   defenderAllies,
 		ScoreDescription: ScoreDescription(war, *attacker, *defender),
+		Battles:          RenderBattles(war, *attacker, *defender),
+		Outcome:          OutcomeDescription(war, *attacker, *defender),
+		CeasefireUntil:   war.CeasefireUntilYear,
 	}, nil
 }
 
@@ -1,7 +1,6 @@
 package war
 
 import (
-	"math"
 	"testing"
 
 	"github.com/brickman1444/NSImperialism/databasemap"
@@ -55,67 +54,6 @@ func TestNewWarIsOngoing(t *testing.T) {
 	assert.True(t, war.IsOngoing)
 }
 
-func TestATickedWarChangesScore(t *testing.T) {
-
-	defender := nationstates_api.Nation{Id: "defender"}
-	defender.SetDefenseForces(50)
-
-	attacker := nationstates_api.Nation{Id: "attacker"}
-	attacker.SetDefenseForces(0)
-
-	nationStatesProvider := nationstates_api.NewNationStatesProviderSimpleMap()
-	nationStatesProvider.PutNationData(defender)
-	nationStatesProvider.PutNationData(attacker)
-
-	year := 0
-	war := databasemap.NewWar(attacker.Id, defender.Id, "", "", year)
-
-	scoreTurnZero := war.Score
-	assert.Equal(t, 0, scoreTurnZero)
-	year++
-
-	Tick(&war, nationStatesProvider, year)
-
-	scoreTurnOne := war.Score
-	assert.NotEqual(t, scoreTurnZero, scoreTurnOne)
-	year++
-
-	Tick(&war, nationStatesProvider, year)
-
-	scoreTurnTwo := war.Score
-	assert.NotEqual(t, scoreTurnOne, scoreTurnTwo)
-}
-
-func TestATickedWarCanEnd(t *testing.T) {
-
-	defender := nationstates_api.Nation{Id: "defender"}
-	defender.SetDefenseForces(50)
-
-	attacker := nationstates_api.Nation{Id: "attacker"}
-	attacker.SetDefenseForces(0)
-
-	nationStatesProvider := nationstates_api.NewNationStatesProviderSimpleMap()
-	nationStatesProvider.PutNationData(defender)
-	nationStatesProvider.PutNationData(attacker)
-
-	year := 0
-	war := databasemap.NewWar(attacker.Id, defender.Id, "", "", year)
-
-	assert.True(t, war.IsOngoing)
-
-	maximumTurnCount := 1000
-	finalTickResult := false
-	for war.IsOngoing && year < maximumTurnCount {
-		tickResult, err := Tick(&war, nationStatesProvider, year)
-		assert.NoError(t, err)
-		year++
-		finalTickResult = tickResult
-	}
-
-	assert.True(t, finalTickResult)
-	assert.False(t, war.IsOngoing)
-}
-
 func TestFindOngoingWarFindsAWar(t *testing.T) {
 	warAtA := databasemap.NewWar("", "", "warAtA", "A", 0)
 	warAtB := databasemap.NewWar("", "", "warAtB", "A", 0)
@@ -134,85 +72,25 @@ func TestFindOngoingWarDoesntReturnACompletedWar(t *testing.T) {
 	assert.Nil(t, foundWar)
 }
 
-func TestMorePowerfulNationDoesntAlwaysWinWar(t *testing.T) {
-
-	nationStatesProvider := nationstates_api.NewNationStatesProviderSimpleMap()
-
-	defender := &nationstates_api.Nation{Id: "Defender"}
-	defender.SetDefenseForces(60)
-	nationStatesProvider.PutNationData(*defender)
-
-	attacker := &nationstates_api.Nation{Id: "Attacker"}
-	attacker.SetDefenseForces(40)
-	nationStatesProvider.PutNationData(*attacker)
-
-	attackerWinCount := 0
-	defenderWinCount := 0
-
-	totalNumberOfSimulations := 10000
-
-	for warIndex := 0; warIndex < totalNumberOfSimulations; warIndex++ {
-		year := 0
-		war := databasemap.NewWar(attacker.Id, defender.Id, "", "", year)
-
-		for war.IsOngoing {
-			Tick(&war, nationStatesProvider, year)
-			year++
-		}
-
-		winnerID := WarAdvantage(war)
-		if winnerID != nil && *winnerID == attacker.Id {
-			attackerWinCount++
-		} else {
-			defenderWinCount++
-		}
-	}
-
-	assert.Greater(t, attackerWinCount, totalNumberOfSimulations/10)
-	assert.Greater(t, defenderWinCount, totalNumberOfSimulations/10)
-	assert.Greater(t, attackerWinCount, defenderWinCount)
-}
+func TestRenderedWarIncludesBattles(t *testing.T) {
 
-func TestWarsDontTakeALongTimeToResolve(t *testing.T) {
+	defender := nationstates_api.Nation{Id: "defender", Name: "Defender"}
+	attacker := nationstates_api.Nation{Id: "attacker", Name: "Attacker"}
 
 	nationStatesProvider := nationstates_api.NewNationStatesProviderSimpleMap()
+	nationStatesProvider.PutNationData(defender)
+	nationStatesProvider.PutNationData(attacker)
 
-	defender := &nationstates_api.Nation{Id: "Defender"}
-	defender.SetDefenseForces(60)
-	nationStatesProvider.PutNationData(*defender)
-
-	attacker := &nationstates_api.Nation{Id: "Attacker"}
-	attacker.SetDefenseForces(40)
-	nationStatesProvider.PutNationData(*attacker)
-
-	totalLength := 0
-	minimumLength := math.MaxInt32
-	maximumLength := 0
-
-	totalNumberOfSimulations := 10000
-
-	for warIndex := 0; warIndex < totalNumberOfSimulations; warIndex++ {
-		year := 0
-		war := databasemap.NewWar(attacker.Id, defender.Id, "", "", year)
-
-		for war.IsOngoing {
-			Tick(&war, nationStatesProvider, year)
-			year++
-		}
-
-		totalLength = totalLength + year
-
-		if year < minimumLength {
-			minimumLength = year
-		}
-		if year > maximumLength {
-			maximumLength = year
-		}
-	}
-
-	averageLength := float32(totalLength) / float32(totalNumberOfSimulations)
-
-	assert.Greater(t, minimumLength, 4)
-	assert.Less(t, maximumLength, 25)
-	assert.Less(t, averageLength, float32(9))
+	war := databasemap.NewWar(attacker.Id, defender.Id, "", "", 0)
+	war.Battles = append(war.Battles, databasemap.DatabaseBattle{Year: 1, Winner: attacker.Id, ScoreDelta: 10})
+	war.Battles = append(war.Battles, databasemap.DatabaseBattle{Year: 2, TerritoryID: "B", Winner: defender.Id, ScoreDelta: -20, RegimentsLost: 3})
+
+	renderedWar, err := RenderWar(war, nationStatesProvider)
+	assert.NoError(t, err)
+	assert.Len(t, renderedWar.Battles, 2)
+	assert.Equal(t, attacker.FlagAndName(), renderedWar.Battles[0].Winner)
+	assert.Equal(t, defender.FlagAndName(), renderedWar.Battles[1].Winner)
+	assert.Equal(t, 20, renderedWar.Battles[1].ScoreDelta)
+	assert.Equal(t, "B", renderedWar.Battles[1].Territory)
+	assert.Equal(t, 3, renderedWar.Battles[1].RegimentsLost)
 }